@@ -119,7 +119,9 @@ func (g *exampleGame) Init() {
 	g.pos = input.Vec{X: 200, Y: 200}
 
 	keymap := input.Keymap{
-		ActionMove:            {input.KeyGamepadLStickMotion},
+		// KeyVector2 turns WASD into a stick-like direction vector,
+		// so the keyboard movement is handled by the same code.
+		ActionMove:            {input.KeyGamepadLStickMotion, input.KeyVector2(input.KeyW, input.KeyS, input.KeyA, input.KeyD)},
 		ActionAlternativeMove: {input.KeyGamepadRStickMotion},
 	}
 
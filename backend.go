@@ -0,0 +1,115 @@
+package input
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Backend is a source of the raw input devices state.
+//
+// System never reads the ebitengine input state directly,
+// all of the device queries go through the backend instead.
+// By default, EbitenBackend is used, but it's possible to
+// provide a custom implementation via SystemConfig.Backend.
+//
+// A custom backend is useful for the headless testing
+// (see inputtest package) or for feeding the input from
+// non-ebitengine sources, like a network connection.
+//
+// The method semantics follow the ebiten and inpututil package functions
+// of the same name. The just-pressed and just-released states are
+// expected to be computed per frame, like inpututil does.
+//
+// Experimental: this is a part of the backend API, which is not stable yet.
+type Backend interface {
+	// Update is called by System.Update before any other backend method
+	// is used during the frame.
+	//
+	// Backends that compute the just-pressed/just-released states
+	// by themselves can use this method to advance the frame.
+	// The ebitengine backend does nothing here.
+	Update()
+
+	IsKeyPressed(k ebiten.Key) bool
+	IsKeyJustPressed(k ebiten.Key) bool
+	IsKeyJustReleased(k ebiten.Key) bool
+	KeyPressDuration(k ebiten.Key) int
+	AppendPressedKeys(keys []ebiten.Key) []ebiten.Key
+	AppendJustPressedKeys(keys []ebiten.Key) []ebiten.Key
+	AppendJustReleasedKeys(keys []ebiten.Key) []ebiten.Key
+
+	IsMouseButtonPressed(b ebiten.MouseButton) bool
+	IsMouseButtonJustPressed(b ebiten.MouseButton) bool
+	IsMouseButtonJustReleased(b ebiten.MouseButton) bool
+	CursorPosition() (x, y int)
+	Wheel() (xoff, yoff float64)
+
+	AppendTouchIDs(touches []ebiten.TouchID) []ebiten.TouchID
+	AppendJustPressedTouchIDs(touches []ebiten.TouchID) []ebiten.TouchID
+	IsTouchJustReleased(id ebiten.TouchID) bool
+	TouchPosition(id ebiten.TouchID) (x, y int)
+
+	AppendGamepadIDs(gamepadIDs []ebiten.GamepadID) []ebiten.GamepadID
+	GamepadName(id ebiten.GamepadID) string
+	GamepadAxisCount(id ebiten.GamepadID) int
+	GamepadAxisValue(id ebiten.GamepadID, axis int) float64
+	IsGamepadButtonPressed(id ebiten.GamepadID, button ebiten.GamepadButton) bool
+	IsGamepadButtonJustPressed(id ebiten.GamepadID, button ebiten.GamepadButton) bool
+	IsGamepadButtonJustReleased(id ebiten.GamepadID, button ebiten.GamepadButton) bool
+	AppendJustPressedGamepadButtons(id ebiten.GamepadID, buttons []ebiten.GamepadButton) []ebiten.GamepadButton
+	AppendJustReleasedGamepadButtons(id ebiten.GamepadID, buttons []ebiten.GamepadButton) []ebiten.GamepadButton
+
+	IsStandardGamepadLayoutAvailable(id ebiten.GamepadID) bool
+	StandardGamepadAxisValue(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) float64
+	IsStandardGamepadButtonPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool
+	IsStandardGamepadButtonJustPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool
+	IsStandardGamepadButtonJustReleased(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool
+}
+
+// AnalogButtonBackend is an optional Backend extension that reports
+// the analog gamepad button values, like the trigger pressure.
+//
+// It's a separate interface, so the custom backends that don't
+// implement it are still valid. For such backends, the standard gamepad
+// buttons are treated as digital ones: 1 if pressed and 0 otherwise.
+//
+// EbitenBackend and the backends provided by this library implement it.
+//
+// Experimental: this is a part of the backend API, which is not stable yet.
+type AnalogButtonBackend interface {
+	StandardGamepadButtonValue(id ebiten.GamepadID, button ebiten.StandardGamepadButton) float64
+}
+
+// FrameDeltaBackend is an optional Backend extension for the backends
+// that need the frame time deltas, like Recorder and Player.
+//
+// If the system backend implements it, System.UpdateWithDelta calls
+// UpdateWithDelta instead of Update. The returned time delta is used
+// by the system for this frame instead of the one passed to UpdateWithDelta.
+//
+// Experimental: this is a part of the backend API, which is not stable yet.
+type FrameDeltaBackend interface {
+	UpdateWithDelta(delta float64) float64
+}
+
+// updateBackend advances the backend frame using the
+// FrameDeltaBackend extension, if it's available.
+// It returns the time delta for this frame.
+func updateBackend(b Backend, delta float64) float64 {
+	if frameDelta, ok := b.(FrameDeltaBackend); ok {
+		return frameDelta.UpdateWithDelta(delta)
+	}
+	b.Update()
+	return delta
+}
+
+// standardGamepadButtonValue returns the button value using the
+// AnalogButtonBackend extension, if it's available.
+func standardGamepadButtonValue(b Backend, id ebiten.GamepadID, button ebiten.StandardGamepadButton) float64 {
+	if analog, ok := b.(AnalogButtonBackend); ok {
+		return analog.StandardGamepadButtonValue(id, button)
+	}
+	if b.IsStandardGamepadButtonPressed(id, button) {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,142 @@
+package input
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// EbitenBackend is a Backend implementation that reads
+// the input state using the ebiten and inpututil packages.
+//
+// This is a default System backend.
+// A zero value is ready to be used.
+type EbitenBackend struct{}
+
+var _ Backend = EbitenBackend{}
+var _ AnalogButtonBackend = EbitenBackend{}
+
+func (EbitenBackend) Update() {}
+
+func (EbitenBackend) IsKeyPressed(k ebiten.Key) bool {
+	return ebiten.IsKeyPressed(k)
+}
+
+func (EbitenBackend) IsKeyJustPressed(k ebiten.Key) bool {
+	return inpututil.IsKeyJustPressed(k)
+}
+
+func (EbitenBackend) IsKeyJustReleased(k ebiten.Key) bool {
+	return inpututil.IsKeyJustReleased(k)
+}
+
+func (EbitenBackend) KeyPressDuration(k ebiten.Key) int {
+	return inpututil.KeyPressDuration(k)
+}
+
+func (EbitenBackend) AppendPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	return inpututil.AppendPressedKeys(keys)
+}
+
+func (EbitenBackend) AppendJustPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	return inpututil.AppendJustPressedKeys(keys)
+}
+
+func (EbitenBackend) AppendJustReleasedKeys(keys []ebiten.Key) []ebiten.Key {
+	return inpututil.AppendJustReleasedKeys(keys)
+}
+
+func (EbitenBackend) IsMouseButtonPressed(b ebiten.MouseButton) bool {
+	return ebiten.IsMouseButtonPressed(b)
+}
+
+func (EbitenBackend) IsMouseButtonJustPressed(b ebiten.MouseButton) bool {
+	return inpututil.IsMouseButtonJustPressed(b)
+}
+
+func (EbitenBackend) IsMouseButtonJustReleased(b ebiten.MouseButton) bool {
+	return inpututil.IsMouseButtonJustReleased(b)
+}
+
+func (EbitenBackend) CursorPosition() (x, y int) {
+	return ebiten.CursorPosition()
+}
+
+func (EbitenBackend) Wheel() (xoff, yoff float64) {
+	return ebiten.Wheel()
+}
+
+func (EbitenBackend) AppendTouchIDs(touches []ebiten.TouchID) []ebiten.TouchID {
+	return ebiten.AppendTouchIDs(touches)
+}
+
+func (EbitenBackend) AppendJustPressedTouchIDs(touches []ebiten.TouchID) []ebiten.TouchID {
+	return inpututil.AppendJustPressedTouchIDs(touches)
+}
+
+func (EbitenBackend) IsTouchJustReleased(id ebiten.TouchID) bool {
+	return inpututil.IsTouchJustReleased(id)
+}
+
+func (EbitenBackend) TouchPosition(id ebiten.TouchID) (x, y int) {
+	return ebiten.TouchPosition(id)
+}
+
+func (EbitenBackend) AppendGamepadIDs(gamepadIDs []ebiten.GamepadID) []ebiten.GamepadID {
+	return ebiten.AppendGamepadIDs(gamepadIDs)
+}
+
+func (EbitenBackend) GamepadName(id ebiten.GamepadID) string {
+	return ebiten.GamepadName(id)
+}
+
+func (EbitenBackend) GamepadAxisCount(id ebiten.GamepadID) int {
+	return ebiten.GamepadAxisCount(id)
+}
+
+func (EbitenBackend) GamepadAxisValue(id ebiten.GamepadID, axis int) float64 {
+	return ebiten.GamepadAxisValue(id, axis)
+}
+
+func (EbitenBackend) IsGamepadButtonPressed(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
+	return ebiten.IsGamepadButtonPressed(id, button)
+}
+
+func (EbitenBackend) IsGamepadButtonJustPressed(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
+	return inpututil.IsGamepadButtonJustPressed(id, button)
+}
+
+func (EbitenBackend) IsGamepadButtonJustReleased(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
+	return inpututil.IsGamepadButtonJustReleased(id, button)
+}
+
+func (EbitenBackend) AppendJustPressedGamepadButtons(id ebiten.GamepadID, buttons []ebiten.GamepadButton) []ebiten.GamepadButton {
+	return inpututil.AppendJustPressedGamepadButtons(id, buttons)
+}
+
+func (EbitenBackend) AppendJustReleasedGamepadButtons(id ebiten.GamepadID, buttons []ebiten.GamepadButton) []ebiten.GamepadButton {
+	return inpututil.AppendJustReleasedGamepadButtons(id, buttons)
+}
+
+func (EbitenBackend) IsStandardGamepadLayoutAvailable(id ebiten.GamepadID) bool {
+	return ebiten.IsStandardGamepadLayoutAvailable(id)
+}
+
+func (EbitenBackend) StandardGamepadAxisValue(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) float64 {
+	return ebiten.StandardGamepadAxisValue(id, axis)
+}
+
+func (EbitenBackend) IsStandardGamepadButtonPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	return ebiten.IsStandardGamepadButtonPressed(id, button)
+}
+
+func (EbitenBackend) StandardGamepadButtonValue(id ebiten.GamepadID, button ebiten.StandardGamepadButton) float64 {
+	return ebiten.StandardGamepadButtonValue(id, button)
+}
+
+func (EbitenBackend) IsStandardGamepadButtonJustPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	return inpututil.IsStandardGamepadButtonJustPressed(id, button)
+}
+
+func (EbitenBackend) IsStandardGamepadButtonJustReleased(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	return inpututil.IsStandardGamepadButtonJustReleased(id, button)
+}
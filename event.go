@@ -42,6 +42,7 @@ type SimulatedAction struct {
 //
 // StartPos is only set for a few events where it makes sense.
 // A drag event, for instance, will store the "dragging from" location there.
+// For two-finger touch gestures, Pos and StartPos are the current and the initial gesture centers.
 //
 // Duration carries the key press duration if available.
 // Duration specifies how long the key has been pressed in ticks same as inpututil.KeyPressDuration.
@@ -49,13 +50,41 @@ type SimulatedAction struct {
 // Use HasDuration() predicate to know whether there is a duration associated
 // with the event to distinguish between 0 duration and lack of duration info.
 type EventInfo struct {
-	kind        keyKind
+	key         Key
 	hasPos      bool
 	hasDuration bool
 
 	Duration int
 	Pos      Vec
 	StartPos Vec
+
+	// ScaleDelta is a relative two-finger distance change since the previous frame.
+	// A value of 0.1 means that the distance increased by 10% (the touches moved apart),
+	// a negative value means that the touches moved together.
+	// On the first frame of the gesture, the change since the gesture start is reported.
+	//
+	// It's only set for the touch gesture keys, like KeyTouchPinch.
+	ScaleDelta float64
+
+	// RotationDelta is a two-finger gesture angle change since the previous frame, in radians.
+	// A positive value means a clockwise rotation (the Y axis points down).
+	// On the first frame of the gesture, the change since the gesture start is reported.
+	//
+	// It's only set for the touch gesture keys, like KeyTouchRotate.
+	RotationDelta float64
+
+	// Velocity is an average swipe velocity, in pixels per second.
+	//
+	// It's only set for the swipe keys, like KeyTouchSwipeUp.
+	Velocity Vec
+
+	// Value is the key analog value, like a trigger pull strength.
+	// It's 1 for the digital keys that are being pressed.
+	// See Handler.ActionValue to learn more.
+	//
+	// For the trigger keys, like KeyGamepadRTrigger, it's a [0, 1] trigger position.
+	// A just released trigger reports its position below the release threshold.
+	Value float64
 }
 
 // HasPos reports whether this event has a position associated with it.
@@ -104,14 +133,29 @@ func (e EventInfo) IsGamepadEvent() bool {
 // A simulated event would have a zero mask returned, meaning
 // no real device was involved.
 func (e EventInfo) Source() DeviceKind {
-	return e.kind.device()
+	return keyDevice(e.key)
 }
 
 type simulatedEvent struct {
 	code     int
 	keyKind  keyKind
+	keyMod   KeyModifier
 	playerID uint8
 
 	pos      Vec
 	startPos Vec
 }
+
+type simulatedHoldChange struct {
+	e       simulatedEvent
+	release bool
+}
+
+func heldEventIndex(slice []simulatedEvent, e simulatedEvent) int {
+	for i := range slice {
+		if slice[i].code == e.code && slice[i].keyKind == e.keyKind && slice[i].keyMod == e.keyMod && slice[i].playerID == e.playerID {
+			return i
+		}
+	}
+	return -1
+}
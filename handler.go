@@ -4,7 +4,6 @@ import (
 	"math"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 // Handler is used to associate a keymap with an abstract input consumer.
@@ -36,12 +35,187 @@ type Handler struct {
 	//
 	// Note that this is a per-handler option.
 	// Different gamepads/devices can have different deadzone values.
+	//
+	// See LeftStickConfig and RightStickConfig for the other deadzone shapes.
 	GamepadDeadzone float64
+
+	// LeftStickConfig and RightStickConfig describe the sticks deadzone model
+	// and the response curve, see StickConfig.
+	//
+	// Like GamepadDeadzone, these can be adjusted on the fly.
+	LeftStickConfig  StickConfig
+	RightStickConfig StickConfig
+
+	// StrictModifiers enables the exact keys matching mode.
+	//
+	// In this mode, a key is ignored while a more specific key
+	// from the same keymap is active. A key is more specific if it
+	// has a superset of the modifiers (ctrl+shift+s is more specific than ctrl+s
+	// and ctrl+s is more specific than s) or if it's a chord that includes
+	// that key (gamepad_l1+gamepad_a is more specific than gamepad_a).
+	//
+	// Given a keymap with ActionSave={ctrl+s} and ActionMoveDown={s},
+	// pressing ctrl+s would activate only the ActionSave.
+	// Without this option, both actions would be activated.
+	//
+	// The more specific keys are collected from the handler keymap.
+	// If the keymap is modified, use Remap to apply the changes.
+	//
+	// This option is disabled by default.
+	StrictModifiers bool
+
+	// specificKeys maps a key to a list of the more specific keymap keys.
+	// It's only used in StrictModifiers mode and it's built on demand.
+	specificKeys map[Key][]Key
+
+	// keyStates holds the stateful keys data, like sequences progress.
+	// keyStateList has the same states ordered for the update.
+	// See keyState comment to learn more.
+	keyStates    map[Key]*keyState
+	keyStateList []*keyState
+	tick         int
+	registered   bool
+	removed      bool
+
+	// AxisLastPressedWins changes how the KeyAxis and KeyVector2 keys
+	// handle the opposing keys that are pressed at the same time.
+	//
+	// By default, the opposing keys cancel each other out:
+	// pressing both left and right results in a zero value.
+	// With this option enabled, the most recently pressed key wins.
+	//
+	// This option is disabled by default.
+	AxisLastPressedWins bool
+
+	// AxisRawDiagonals disables the KeyVector2 diagonal normalization.
+	//
+	// By default, the vector length is clamped to 1, so pressing
+	// up and right results in a {0.707, -0.707} vector.
+	// With this option enabled, the same input results in a {1, -1} vector.
+	//
+	// This option is disabled by default.
+	AxisRawDiagonals bool
+
+	// TriggerThreshold is a trigger value required to activate
+	// the KeyGamepadLTrigger and KeyGamepadRTrigger keys.
+	//
+	// The default value is 0.5, meaning the trigger needs to be pulled halfway.
+	// Use KeyTriggerThreshold to override it for a specific key.
+	TriggerThreshold float64
+
+	// TriggerHysteresis is subtracted from the trigger threshold
+	// to get a value the trigger needs to go below to be released.
+	// It prevents the key from flickering when the trigger
+	// is being held right around the threshold.
+	// If the trigger key was not checked during the previous frame,
+	// its previous state is computed without the hysteresis.
+	//
+	// The default value is 0.05.
+	TriggerHysteresis float64
+
+	// StickDPad describes how the stick direction keys,
+	// like KeyGamepadLStickUp, emulate the D-pad buttons.
+	// See StickDPadConfig to learn more.
+	//
+	// It can be adjusted on the fly.
+	StickDPad StickDPadConfig
+
+	// stickDPad holds the left and right sticks emulated D-pad state.
+	// triggerStates holds the trigger keys hysteresis state.
+	stickDPad     [2]stickDPadState
+	triggerStates map[Key]*triggerState
+
+	// bufferTicks is an input buffer window, see SetBufferWindow.
+	// bufferedActions holds the presses that are not consumed yet.
+	bufferTicks     int
+	bufferedActions map[Action]bufferedAction
+
+	// virtualGamepad is an optional on-screen gamepad, see SetVirtualGamepad.
+	virtualGamepad *VirtualGamepad
+
+	// contexts is the context stack, see PushContext.
+	// baseKeymap is the bottom layer keymap, the one that is set by Remap.
+	// If the stack is not empty, the keymap field holds the merged keymap.
+	// hiddenKeyStates holds the states of the keys hidden by the contexts,
+	// they're not updated until the keys are visible again.
+	contexts        []Context
+	baseKeymap      Keymap
+	hiddenKeyStates map[Key]*keyState
 }
 
 // Remap changes the handler keymap while keeping all other settings the same.
+//
+// If there are some contexts in the handler stack (see PushContext),
+// only the bottom layer keymap is replaced.
 func (h *Handler) Remap(keymap Keymap) {
-	h.keymap = keymap
+	h.baseKeymap = keymap
+	h.keymap = h.contextKeymap()
+	h.specificKeys = nil
+	h.bufferedActions = nil
+	h.hiddenKeyStates = nil
+	h.initKeyStates()
+}
+
+// SetBufferWindow enables the input buffering for this handler.
+// Use a non-positive value to disable it.
+//
+// With the input buffering, the handler remembers the just pressed
+// keymap actions for the given number of ticks (System.Update calls).
+// Use ConsumeBuffered to check whether the action was pressed recently.
+//
+// A typical use case is a platformer jump: a jump pressed a few frames
+// before the character lands should still be performed.
+func (h *Handler) SetBufferWindow(ticks int) {
+	if ticks <= 0 {
+		ticks = 0
+		h.bufferedActions = nil
+	}
+	h.bufferTicks = ticks
+	h.register()
+}
+
+// ConsumeBuffered reports whether the action was just pressed during this frame
+// or during the buffer window ticks before it (see SetBufferWindow).
+// A buffered press can be consumed only once: the next call returns false
+// until the action is pressed again.
+//
+// Both real and simulated events are buffered.
+// It always returns false if the input buffering is disabled.
+func (h *Handler) ConsumeBuffered(action Action) bool {
+	pressed, ok := h.bufferedActions[action]
+	if !ok {
+		return false
+	}
+	delete(h.bufferedActions, action)
+	if h.tick-pressed.tick > h.bufferTicks {
+		return false
+	}
+	if pressed.key.kind != keySimulated {
+		h.updateLastDevice(pressed.key)
+	}
+	return true
+}
+
+// SetVirtualGamepad binds an on-screen gamepad to this handler.
+// Use nil to unbind the current virtual gamepad.
+//
+// The virtual gamepad buttons and sticks act like the gamepad keys
+// of this handler, they're handled by the same keymap.
+// The virtual events are reported as gamepad events.
+//
+// It panics if any of the virtual buttons is not bound to a gamepad button key.
+//
+// Experimental: this is a part of virtual input API, which is not stable yet.
+func (h *Handler) SetVirtualGamepad(g *VirtualGamepad) {
+	if g != nil {
+		for _, b := range g.Buttons {
+			if b.Key.kind != keyGamepad {
+				panic("virtual button key should be a gamepad button")
+			}
+		}
+	}
+	h.virtualGamepad = g
+	h.register()
 }
 
 // GamepadConnected reports whether the gamepad associated with this handler is connected.
@@ -68,6 +242,19 @@ func (h *Handler) CursorPos() Vec {
 	return h.sys.cursorPos
 }
 
+// AppendTouches appends all tracked screen touches to dst and returns the extended slice.
+//
+// This includes the active touches and the touches that were released during this frame.
+// The touches are ordered by their start time, the oldest touch goes first.
+// See TouchInfo documentation for more info.
+//
+// It returns dst unchanged if touch events are not enabled.
+//
+// Experimental: touch API is not stable yet!
+func (h *Handler) AppendTouches(dst []TouchInfo) []TouchInfo {
+	return append(dst, h.sys.touches...)
+}
+
 // DefaultInputMask returns the input mask suitable for functions like ActionKeyNames.
 //
 // If gamepad is connected, it returns GamepadDevice mask.
@@ -88,7 +275,8 @@ func (h *Handler) DefaultInputMask() DeviceKind {
 //
 // Note: simulated events are only visible after the next System.Update() call.
 //
-// Note: key release events can't be simulated yet (see #35).
+// The emitted key is pressed for a single frame only.
+// Use EmitKeyHold if you need a key that remains pressed until released.
 //
 // See SimulatedKeyEvent documentation for more info.
 //
@@ -97,6 +285,7 @@ func (h *Handler) EmitKeyEvent(e SimulatedKeyEvent) {
 	h.sys.pendingEvents = append(h.sys.pendingEvents, simulatedEvent{
 		code:     e.Key.code,
 		keyKind:  e.Key.kind,
+		keyMod:   e.Key.mod,
 		playerID: h.id,
 		pos:      e.Pos,
 	})
@@ -107,7 +296,8 @@ func (h *Handler) EmitKeyEvent(e SimulatedKeyEvent) {
 //
 // Note: simulated events are only visible after the next System.Update() call.
 //
-// Note: action release events can't be simulated yet (see #35).
+// The emitted action is active for a single frame only.
+// Use EmitHold if you need an action that remains active until released.
 //
 // See SimulatedAction documentation for more info.
 //
@@ -122,20 +312,105 @@ func (h *Handler) EmitEvent(e SimulatedAction) {
 	})
 }
 
+// EmitKeyHold is like EmitKeyEvent, but the key remains pressed
+// until the EmitKeyRelease is called for it.
+//
+// The first frame of the hold is reported as "just pressed" and
+// the first frame after the release is reported as "just released".
+//
+// Emitting a hold for a key that is already being held only
+// updates its event data (like Pos), the press state is unaffected.
+//
+// Note: simulated events are only visible after the next System.Update() call.
+// A key that is held and released before that call will not be pressed at all.
+//
+// Experimental: this is a part of virtual input API, which is not stable yet.
+func (h *Handler) EmitKeyHold(e SimulatedKeyEvent) {
+	h.sys.pendingHoldChanges = append(h.sys.pendingHoldChanges, simulatedHoldChange{
+		e: simulatedEvent{
+			code:     e.Key.code,
+			keyKind:  e.Key.kind,
+			keyMod:   e.Key.mod,
+			playerID: h.id,
+			pos:      e.Pos,
+		},
+	})
+}
+
+// EmitKeyRelease releases the key that was held by EmitKeyHold.
+//
+// Note: simulated events are only visible after the next System.Update() call.
+//
+// Experimental: this is a part of virtual input API, which is not stable yet.
+func (h *Handler) EmitKeyRelease(k Key) {
+	h.sys.pendingHoldChanges = append(h.sys.pendingHoldChanges, simulatedHoldChange{
+		e: simulatedEvent{
+			code:     k.code,
+			keyKind:  k.kind,
+			keyMod:   k.mod,
+			playerID: h.id,
+		},
+		release: true,
+	})
+}
+
+// EmitHold is like EmitEvent, but the action remains active
+// until the EmitRelease is called for it.
+//
+// See EmitKeyHold documentation for more info.
+//
+// Experimental: this is a part of virtual input API, which is not stable yet.
+func (h *Handler) EmitHold(e SimulatedAction) {
+	h.sys.pendingHoldChanges = append(h.sys.pendingHoldChanges, simulatedHoldChange{
+		e: simulatedEvent{
+			code:     int(e.Action),
+			keyKind:  keySimulated,
+			playerID: h.id,
+			pos:      e.Pos,
+			startPos: e.StartPos,
+		},
+	})
+}
+
+// EmitRelease releases the action that was held by EmitHold.
+//
+// Note: simulated events are only visible after the next System.Update() call.
+//
+// Experimental: this is a part of virtual input API, which is not stable yet.
+func (h *Handler) EmitRelease(action Action) {
+	h.sys.pendingHoldChanges = append(h.sys.pendingHoldChanges, simulatedHoldChange{
+		e: simulatedEvent{
+			code:     int(action),
+			keyKind:  keySimulated,
+			playerID: h.id,
+		},
+		release: true,
+	})
+}
+
 // AnyKeyJustReleased is like AnyKeyJustPressed, but for released key state.
 func (h *Handler) AnyKeyJustReleased() bool {
-	h.sys.keySlice = inpututil.AppendJustReleasedKeys(h.sys.keySlice[:0])
+	h.sys.keySlice = h.sys.backend.AppendJustReleasedKeys(h.sys.keySlice[:0])
 	if len(h.sys.keySlice) != 0 {
 		return true
 	}
 
 	if len(h.sys.gamepadIDs) != 0 {
-		h.sys.gamepadKeySlice = inpututil.AppendJustReleasedGamepadButtons(ebiten.GamepadID(h.id), h.sys.gamepadKeySlice[:0])
+		h.sys.gamepadKeySlice = h.sys.backend.AppendJustReleasedGamepadButtons(ebiten.GamepadID(h.id), h.sys.gamepadKeySlice[:0])
 		if len(h.sys.gamepadKeySlice) != 0 {
 			return true
 		}
 	}
 
+	for _, e := range h.sys.prevSimulatedEvents {
+		if keyNeedID(e.keyKind) && e.playerID != h.id {
+			continue
+		}
+		if heldEventIndex(h.sys.simulatedEvents, e) == -1 {
+			return true
+		}
+	}
+
 	return false
 }
 
@@ -149,13 +424,13 @@ func (h *Handler) AnyKeyJustReleased() bool {
 //
 // This method does not support gamepad pseudo-keys like KeyGamepadLStickUp.
 func (h *Handler) AnyKeyJustPressed() bool {
-	h.sys.keySlice = inpututil.AppendJustPressedKeys(h.sys.keySlice[:0])
+	h.sys.keySlice = h.sys.backend.AppendJustPressedKeys(h.sys.keySlice[:0])
 	if len(h.sys.keySlice) != 0 {
 		return true
 	}
 
 	if len(h.sys.gamepadIDs) != 0 {
-		h.sys.gamepadKeySlice = inpututil.AppendJustPressedGamepadButtons(ebiten.GamepadID(h.id), h.sys.gamepadKeySlice[:0])
+		h.sys.gamepadKeySlice = h.sys.backend.AppendJustPressedGamepadButtons(ebiten.GamepadID(h.id), h.sys.gamepadKeySlice[:0])
 		if len(h.sys.gamepadKeySlice) != 0 {
 			return true
 		}
@@ -197,6 +472,9 @@ func (h *Handler) AnyKeyJustPressed() bool {
 // Modifiers are separated by "+".
 // A "k" keyboard key with ctrl modifier will have a "ctrl+k" name.
 //
+// Chord keys are listed only if all of their keys match the mask.
+// A chord name is its key names separated by "+", like "gamepad_l1+gamepad_a".
+//
 // Note: this function doesn't check whether some input device is available or not.
 // For example, if mask contains a TouchDevice, but touch actions are not
 // available on a machine, touch-related keys will still be returned.
@@ -219,28 +497,21 @@ func (h *Handler) ActionKeyNames(action Action, mask DeviceKind) []string {
 
 func (h *Handler) keyIsEnabled(k Key, mask DeviceKind) bool {
 	switch k.kind {
-	case keyKeyboardWithCtrlShift:
-		return mask&KeyboardDevice != 0
-	case keyKeyboardWithCtrl:
-		return mask&KeyboardDevice != 0
-	case keyKeyboardWithShift:
-		return mask&KeyboardDevice != 0
 	case keyKeyboard:
 		return mask&KeyboardDevice != 0
-	case keyMouseWithCtrlShift:
-		return mask&MouseDevice != 0
-	case keyMouseWithCtrl:
-		return mask&MouseDevice != 0
-	case keyMouseWithShift:
-		return mask&MouseDevice != 0
-	case keyMouse:
-		return mask&MouseDevice != 0
-	case keyWheel, keyWheelWithCtrl, keyWheelWithShift, keyWheelWithCtrlShift:
+	case keyMouse, keyMouseSwipe, keyWheel:
 		return mask&MouseDevice != 0
-	case keyGamepad, keyGamepadLeftStick, keyGamepadRightStick, keyGamepadStickMotion:
+	case keyGamepad, keyGamepadLeftStick, keyGamepadRightStick, keyGamepadStickMotion, keyGamepadTrigger:
 		return mask&GamepadDevice != 0
-	case keyTouch, keyTouchDrag:
+	case keyTouch, keyTouchDrag, keyTouchSwipe:
 		return mask&TouchDevice != 0
+	case keyChord, keySequence, keyMultiTap, keyHold, keyTap, keyAxis, keyVector2, keyTriggerThreshold, keyRepeat:
+		for _, member := range getCompositeKey(k).keys {
+			if !h.keyIsEnabled(member, mask) {
+				return false
+			}
+		}
+		return true
 	}
 	return true
 }
@@ -254,28 +525,41 @@ func (h *Handler) keyIsEnabled(k Key, mask DeviceKind) bool {
 //
 // See EventInfo comment to learn more.
 //
-// Note: this action event is never simulated (see #35).
+// For the simulated events, the event info will contain the
+// data of the last frame when the key was being held.
 func (h *Handler) JustReleasedActionInfo(action Action) (EventInfo, bool) {
 	keys, ok := h.keymap[action]
 	if !ok {
 		return EventInfo{}, false
 	}
 	for _, k := range keys {
-		if !h.keyIsJustReleased(k) {
+		if len(h.sys.prevSimulatedEvents) != 0 {
+			if info, ok := h.releasedSimulatedKeyInfo(k); ok {
+				return info, true
+			}
+		}
+		if !h.keyIsJustReleased(k) || h.keyIsSuppressed(k, true) {
 			continue
 		}
 		// TODO: maybe move this EventInfo initialization code to a function?
 		// It look like it's the same code in every *ActionInfo method.
-		// We don't need the StartPos here yet, because touch events
-		// are not handled in release events, but that's just a minutiae.
 		var info EventInfo
-		info.kind = k.kind
-		info.hasPos = keyHasPos(k.kind)
+		info.key = k
+		info.hasPos = keyHasPos(k)
 		info.Pos = h.getKeyPos(k)
 		info.StartPos = h.getKeyStartPos(k)
-		h.updateLastDevice(k.kind)
+		info.ScaleDelta, info.RotationDelta = h.getKeyGestureDeltas(k)
+		info.Velocity = h.getKeySwipe(k).velocity
+		info.Value = h.keyValue(k)
+		h.updateLastDevice(k)
 		return info, true
 	}
+	if len(h.sys.prevSimulatedEvents) != 0 {
+		return h.releasedSimulatedKeyInfo(Key{
+			code: int(action),
+			kind: keySimulated,
+		})
+	}
 	return EventInfo{}, false
 }
 
@@ -288,6 +572,9 @@ func (h *Handler) JustReleasedActionInfo(action Action) (EventInfo, bool) {
 //   - Keyboard events
 //   - Mouse events
 //   - Gamepad normal buttons events (doesn't include joystick D-pad emulation events like KeyGamepadLStickUp)
+//   - Chords that consist of the keys listed above
+//   - KeyHold wrapper keys
+//   - Two-finger touch gestures (KeyTouchPinch and KeyTouchRotate)
 //
 // For the keys with modifiers it doesn't require the modifier keys to be released simultaneously with a main key.
 // These modifier keys can be in either "pressed" or "just released" state.
@@ -303,18 +590,33 @@ func (h *Handler) JustReleasedActionInfo(action Action) (EventInfo, bool) {
 // But that's a more complicated task.
 // Let's wait until users report their use cases.
 //
-// Note: this action event is never simulated (see #35).
+// Simulated events are reported as released on the first frame
+// after they stopped being emitted, this includes the single frame
+// events emitted by EmitKeyEvent and EmitEvent.
+// A simulated key release is ignored while the real key is still pressed.
 func (h *Handler) ActionIsJustReleased(action Action) bool {
 	keys, ok := h.keymap[action]
 	if !ok {
 		return false
 	}
 	for _, k := range keys {
-		if h.keyIsJustReleased(k) {
-			h.updateLastDevice(k.kind)
+		if len(h.sys.prevSimulatedEvents) != 0 {
+			if _, ok := h.releasedSimulatedKeyInfo(k); ok {
+				return true
+			}
+		}
+		if h.keyIsJustReleased(k) && !h.keyIsSuppressed(k, true) {
+			h.updateLastDevice(k)
 			return true
 		}
 	}
+	if len(h.sys.prevSimulatedEvents) != 0 {
+		_, ok := h.releasedSimulatedKeyInfo(Key{
+			code: int(action),
+			kind: keySimulated,
+		})
+		return ok
+	}
 	return false
 }
 
@@ -333,15 +635,18 @@ func (h *Handler) JustPressedActionInfo(action Action) (EventInfo, bool) {
 		if info, status := h.pressedSimulatedKeyInfo(true, k); status == bool3true {
 			return info, true
 		}
-		if !h.keyIsJustPressed(k) {
+		if !h.keyIsJustPressed(k) || h.keyIsSuppressed(k, false) {
 			continue
 		}
 		var info EventInfo
-		info.kind = k.kind
-		info.hasPos = keyHasPos(k.kind)
+		info.key = k
+		info.hasPos = keyHasPos(k)
 		info.Pos = h.getKeyPos(k)
 		info.StartPos = h.getKeyStartPos(k)
-		h.updateLastDevice(k.kind)
+		info.ScaleDelta, info.RotationDelta = h.getKeyGestureDeltas(k)
+		info.Velocity = h.getKeySwipe(k).velocity
+		info.Value = h.keyValue(k)
+		h.updateLastDevice(k)
 		return info, true
 	}
 	if h.sys.hasSimulatedActions {
@@ -369,17 +674,20 @@ func (h *Handler) PressedActionInfo(action Action) (EventInfo, bool) {
 		if info, status := h.pressedSimulatedKeyInfo(false, k); status == bool3true {
 			return info, true
 		}
-		if !h.keyIsPressed(k) {
+		if !h.keyIsPressed(k) || h.keyIsSuppressed(k, false) {
 			continue
 		}
 		var info EventInfo
-		info.kind = k.kind
-		info.hasPos = keyHasPos(k.kind)
+		info.key = k
+		info.hasPos = keyHasPos(k)
 		info.Pos = h.getKeyPos(k)
 		info.StartPos = h.getKeyStartPos(k)
-		info.hasDuration = keyHasDuration(k.kind)
+		info.ScaleDelta, info.RotationDelta = h.getKeyGestureDeltas(k)
+		info.Velocity = h.getKeySwipe(k).velocity
+		info.Value = h.keyValue(k)
+		info.hasDuration = keyHasDuration(k)
 		info.Duration = h.getKeyPressDuration(k)
-		h.updateLastDevice(k.kind)
+		h.updateLastDevice(k)
 		return info, true
 	}
 	return EventInfo{}, false
@@ -389,9 +697,20 @@ func (h *Handler) PressedActionInfo(action Action) (EventInfo, bool) {
 // on the action level and works with any kinds of "keys".
 // It returns true if any of the keys bound to the action was pressed during this frame.
 func (h *Handler) ActionIsJustPressed(action Action) bool {
+	k, ok := h.justPressedActionKey(action)
+	if ok && k.kind != keySimulated {
+		h.updateLastDevice(k)
+	}
+	return ok
+}
+
+// justPressedActionKey returns the key that activated the just pressed action.
+// The simulated events are reported as a keySimulated key.
+func (h *Handler) justPressedActionKey(action Action) (Key, bool) {
+	simulatedKey := Key{code: int(action), kind: keySimulated}
 	keys, ok := h.keymap[action]
 	if !ok {
-		return false
+		return Key{}, false
 	}
 	for _, k := range keys {
 		if len(h.sys.simulatedEvents) != 0 {
@@ -403,22 +722,18 @@ func (h *Handler) ActionIsJustPressed(action Action) bool {
 			// holds that button down. This is why we need a bool3 here.
 			_, isPressed := h.pressedSimulatedKeyInfo(true, k)
 			if isPressed != bool3unset {
-				return isPressed == bool3true
+				return simulatedKey, isPressed == bool3true
 			}
 		}
-		if h.keyIsJustPressed(k) {
-			h.updateLastDevice(k.kind)
-			return true
+		if h.keyIsJustPressed(k) && !h.keyIsSuppressed(k, false) {
+			return k, true
 		}
 	}
 	if h.sys.hasSimulatedActions {
-		_, isPressed := h.pressedSimulatedKeyInfo(true, Key{
-			code: int(action),
-			kind: keySimulated,
-		})
-		return isPressed == bool3true
+		_, isPressed := h.pressedSimulatedKeyInfo(true, simulatedKey)
+		return simulatedKey, isPressed == bool3true
 	}
-	return false
+	return Key{}, false
 }
 
 // ActionIsPressed is like ebiten.IsKeyPressed, but operates
@@ -433,8 +748,8 @@ func (h *Handler) ActionIsPressed(action Action) bool {
 		if len(h.sys.simulatedEvents) != 0 && h.simulatedKeyIsPressed(k) {
 			return true
 		}
-		if h.keyIsPressed(k) {
-			h.updateLastDevice(k.kind)
+		if h.keyIsPressed(k) && !h.keyIsSuppressed(k, false) {
+			h.updateLastDevice(k)
 			return true
 		}
 	}
@@ -447,6 +762,236 @@ func (h *Handler) ActionIsPressed(action Action) bool {
 	return false
 }
 
+// ActionHoldProgress returns the hold progress of the action KeyHold and KeyTap keys.
+//
+// The progress is a value in [0, 1] range: 0 means that the key is not being held
+// and 1 means that the hold duration threshold is reached.
+// If several keys are being held, the max progress is returned.
+//
+// This is useful for the UI, like an "interact" button ring being filled.
+func (h *Handler) ActionHoldProgress(action Action) float64 {
+	progress := 0.0
+	for _, k := range h.keymap[action] {
+		if k.kind == keyHold || k.kind == keyTap {
+			progress = math.Max(progress, h.keyHoldProgress(k))
+		}
+	}
+	return progress
+}
+
+// ActionValue returns the analog strength of the action.
+//
+// The value depends on the active key type:
+//   - Gamepad buttons report their pressure, like a half-pressed trigger value of 0.5,
+//     even before the button is considered to be pressed
+//     (if the gamepad has no pressure-sensitive buttons, it's either 0 or 1)
+//   - Stick motion keys report the stick displacement length
+//   - Stick direction keys (like KeyGamepadLStickUp) report the stick displacement in that direction
+//   - Wheel keys report the absolute wheel delta (it can exceed 1)
+//   - KeyAxis keys report a signed value in [-1, 1] range
+//   - KeyVector2 keys report the vector length
+//   - All other keys report 1 while they're pressed
+//
+// If several keys are active, the value with the max magnitude is returned.
+// It returns 0 if the action is not activated.
+//
+// This is useful for the acceleration and throttle controls
+// that can be bound to any device.
+func (h *Handler) ActionValue(action Action) float64 {
+	value := 0.0
+	for _, k := range h.keymap[action] {
+		if v := h.keyValue(k); math.Abs(v) > math.Abs(value) {
+			value = v
+		}
+	}
+	if value == 0 && h.sys.hasSimulatedActions && h.simulatedKeyIsPressed(Key{code: int(action), kind: keySimulated}) {
+		value = 1
+	}
+	return value
+}
+
+// ActionVector is like ActionValue, but it returns a two-dimensional analog value.
+//
+// Stick motion and stick direction keys report the stick vector,
+// wheel keys report the wheel deltas and KeyVector2 keys report their vector.
+// All other keys report their ActionValue as the X component.
+//
+// If several keys are active, the longest vector is returned.
+// It returns a zero vector if the action is not activated.
+func (h *Handler) ActionVector(action Action) Vec {
+	var result Vec
+	resultLen := 0.0
+	for _, k := range h.keymap[action] {
+		v, ok := h.keyVector(k)
+		if !ok {
+			continue
+		}
+		if l := vecLen(v); l > resultLen {
+			result = v
+			resultLen = l
+		}
+	}
+	if resultLen == 0 && h.sys.hasSimulatedActions && h.simulatedKeyIsPressed(Key{code: int(action), kind: keySimulated}) {
+		result = Vec{X: 1}
+	}
+	return result
+}
+
+// keyValue returns the k analog strength, see ActionValue.
+func (h *Handler) keyValue(k Key) float64 {
+	if len(h.sys.simulatedEvents) != 0 && h.simulatedKeyIsPressed(k) {
+		// The simulated analog keys carry their value in the Pos.
+		switch k.kind {
+		case keyGamepadStickMotion, keyVector2:
+			info, _ := h.pressedSimulatedKeyInfo(false, k)
+			return math.Min(vecLen(info.Pos), 1)
+		case keyAxis:
+			info, _ := h.pressedSimulatedKeyInfo(false, k)
+			return info.Pos.X
+		}
+		return 1
+	}
+	if k.kind == keyGamepad && !h.keyIsSuppressed(k, false) {
+		// A slightly pressed trigger has a value even if it's not pressed yet.
+		return h.gamepadKeyValue(k)
+	}
+	if k.kind == keyGamepadTrigger || k.kind == keyTriggerThreshold {
+		// Same as above: the trigger value is reported below the threshold too.
+		if k.kind == keyTriggerThreshold {
+			k = getCompositeKey(k).keys[0]
+		}
+		return h.triggerValue(k, false)
+	}
+	if !h.keyIsPressed(k) || h.keyIsSuppressed(k, false) {
+		return 0
+	}
+	switch k.kind {
+	case keyGamepadLeftStick:
+		return stickDirectionValue(stickCode(k.code), h.stickVec(stickLeft))
+	case keyGamepadRightStick:
+		return stickDirectionValue(stickCode(k.code), h.stickVec(stickRight))
+	case keyGamepadStickMotion:
+		return math.Min(vecLen(h.stickVec(stickCode(k.code))), 1)
+	case keyAxis:
+		return h.keyStates[k].value.X
+	case keyVector2:
+		return vecLen(h.keyStates[k].value)
+	case keyWheel:
+		switch wheelCode(k.code) {
+		case wheelLeft, wheelRight, wheelHorizontal:
+			return math.Abs(h.sys.wheel.X)
+		default:
+			return math.Abs(h.sys.wheel.Y)
+		}
+	}
+	return 1
+}
+
+// keyVector returns the k two-dimensional analog value, see ActionVector.
+// The second return value is false if k is not active.
+func (h *Handler) keyVector(k Key) (Vec, bool) {
+	if len(h.sys.simulatedEvents) != 0 && h.simulatedKeyIsPressed(k) {
+		switch k.kind {
+		case keyGamepadStickMotion, keyVector2, keyAxis:
+			info, _ := h.pressedSimulatedKeyInfo(false, k)
+			return info.Pos, true
+		}
+		return Vec{X: 1}, true
+	}
+	switch k.kind {
+	case keyGamepad, keyGamepadTrigger, keyTriggerThreshold:
+		v := h.keyValue(k)
+		return Vec{X: v}, v != 0
+	}
+	if !h.keyIsPressed(k) || h.keyIsSuppressed(k, false) {
+		return Vec{}, false
+	}
+	switch k.kind {
+	case keyGamepadLeftStick:
+		return h.stickVec(stickLeft), true
+	case keyGamepadRightStick:
+		return h.stickVec(stickRight), true
+	case keyGamepadStickMotion:
+		return h.stickVec(stickCode(k.code)), true
+	case keyVector2:
+		return h.keyStates[k].value, true
+	case keyWheel:
+		return h.sys.wheel, true
+	}
+	return Vec{X: h.keyValue(k)}, true
+}
+
+func (h *Handler) gamepadKeyValue(k Key) float64 {
+	if h.gamepadInfo().model == gamepadStandard {
+		v := standardGamepadButtonValue(h.sys.backend, ebiten.GamepadID(h.id), ebiten.StandardGamepadButton(k.code))
+		if v > 0 {
+			return math.Min(v, 1)
+		}
+	}
+	// The button pressure is unknown.
+	if h.gamepadKeyIsPressed(k) {
+		return 1
+	}
+	return 0
+}
+
+// triggerValue returns the k trigger position in [0, 1] range.
+// If prev is true, the previous frame position is returned.
+func (h *Handler) triggerValue(k Key, prev bool) float64 {
+	info := h.gamepadInfo()
+	right := k.code == int(ebiten.StandardGamepadButtonFrontBottomRight)
+	switch info.model {
+	case gamepadStandard:
+		values := &info.triggerValues
+		if prev {
+			values = &info.prevTriggerValues
+		}
+		i := 0
+		if right {
+			i = 1
+		}
+		return math.Max(0, math.Min(values[i], 1))
+	case gamepadFirefoxXinput:
+		// The triggers are reported as axes there, see gamepadKeyIsPressed.
+		// A released trigger axis rests at -1, a fully pulled one is at 1.
+		values := &info.axisValues
+		if prev {
+			values = &info.prevAxisValues
+		}
+		axis := 2
+		if right {
+			axis = 5
+		}
+		return math.Max(0, math.Min((values[axis]+1)/2, 1))
+	}
+	// The trigger position is unknown.
+	button := Key{code: k.code, kind: keyGamepad}
+	pressed := h.gamepadKeyIsPressed(button)
+	if prev {
+		pressed = (pressed && !h.gamepadKeyIsJustPressed(button)) || h.gamepadKeyIsJustReleased(button)
+	}
+	if pressed {
+		return 1
+	}
+	return 0
+}
+
+// stickDirectionValue returns the vec displacement in the code direction.
+func stickDirectionValue(code stickCode, vec Vec) float64 {
+	var v float64
+	switch code {
+	case stickUp:
+		v = -vec.Y
+	case stickDown:
+		v = vec.Y
+	case stickLeft:
+		v = -vec.X
+	case stickRight:
+		v = vec.X
+	}
+	return math.Max(0, math.Min(v, 1))
+}
+
 // LastDevice returns a set of devices that were used to trigger the last event.
 // May be useful for swapping button prompts when the user changes device.
 func (h *Handler) LastDevice() DeviceKind {
@@ -458,40 +1003,57 @@ func (h *Handler) keyIsJustReleased(k Key) bool {
 	// TODO: extend the supported key kinds list?
 	switch k.kind {
 	case keyMouse:
-		return inpututil.IsMouseButtonJustReleased(ebiten.MouseButton(k.code))
+		return h.modifiersArePressedOrJustReleased(k.mod) &&
+			h.sys.backend.IsMouseButtonJustReleased(ebiten.MouseButton(k.code))
 	case keyMouseDrag:
-		return h.sys.mouseJustReleasedDrag
+		return h.mouseDrag(k).justReleasedDrag
+	case keyTouch:
+		return h.touchGestureIsJustReleased(touchCode(k.code))
 	case keyGamepad:
 		return h.gamepadKeyIsJustReleased(k)
-	case keyMouseWithCtrl:
-		return h.ebitenKeyIsPressedOrJustReleased(ebiten.KeyControl) &&
-			inpututil.IsMouseButtonJustReleased(ebiten.MouseButton(k.code))
-	case keyMouseWithShift:
-		return h.ebitenKeyIsPressedOrJustReleased(ebiten.KeyShift) &&
-			inpututil.IsMouseButtonJustReleased(ebiten.MouseButton(k.code))
-	case keyMouseWithCtrlShift:
-		return h.ebitenKeyIsPressedOrJustReleased(ebiten.KeyControl) &&
-			h.ebitenKeyIsPressedOrJustReleased(ebiten.KeyShift) &&
-			inpututil.IsMouseButtonJustReleased(ebiten.MouseButton(k.code))
-	case keyKeyboardWithCtrl:
-		return h.ebitenKeyIsPressedOrJustReleased(ebiten.KeyControl) &&
-			inpututil.IsKeyJustReleased(ebiten.Key(k.code))
-	case keyKeyboardWithShift:
-		return h.ebitenKeyIsPressedOrJustReleased(ebiten.KeyShift) &&
-			inpututil.IsKeyJustReleased(ebiten.Key(k.code))
-	case keyKeyboardWithCtrlShift:
-		return h.ebitenKeyIsPressedOrJustReleased(ebiten.KeyControl) &&
-			h.ebitenKeyIsPressedOrJustReleased(ebiten.KeyShift) &&
-			inpututil.IsKeyJustReleased(ebiten.Key(k.code))
 	case keyKeyboard:
-		return inpututil.IsKeyJustReleased(ebiten.Key(k.code))
+		return h.modifiersArePressedOrJustReleased(k.mod) &&
+			h.sys.backend.IsKeyJustReleased(ebiten.Key(k.code))
+	case keyChord:
+		return h.chordIsJustReleased(k)
+	case keyGamepadLeftStick, keyGamepadRightStick:
+		return h.gamepadStickIsJustReleased(k)
+	case keyGamepadTrigger, keyTriggerThreshold:
+		st := h.triggerState(k)
+		return !st.pressed && st.prevPressed
+	case keyHold, keyAxis, keyVector2, keyRepeat:
+		st := h.keyStates[k]
+		return st != nil && st.justReleased
 	default:
 		return false
 	}
 }
 
 func (h *Handler) ebitenKeyIsPressedOrJustReleased(k ebiten.Key) bool {
-	return ebiten.IsKeyPressed(k) || inpututil.IsKeyJustReleased(k)
+	return h.sys.backend.IsKeyPressed(k) || h.sys.backend.IsKeyJustReleased(k)
+}
+
+// modifiersArePressed reports whether all of the mod keys are being pressed.
+// Note that it doesn't check whether other modifiers are pressed:
+// a ctrl+k key is activated by ctrl+shift+k combination too.
+func (h *Handler) modifiersArePressed(mod KeyModifier) bool {
+	if mod == 0 {
+		return true
+	}
+	return (mod&ModControl == 0 || h.sys.backend.IsKeyPressed(ebiten.KeyControl)) &&
+		(mod&ModShift == 0 || h.sys.backend.IsKeyPressed(ebiten.KeyShift)) &&
+		(mod&ModAlt == 0 || h.sys.backend.IsKeyPressed(ebiten.KeyAlt)) &&
+		(mod&ModMeta == 0 || h.sys.backend.IsKeyPressed(ebiten.KeyMeta))
+}
+
+func (h *Handler) modifiersArePressedOrJustReleased(mod KeyModifier) bool {
+	if mod == 0 {
+		return true
+	}
+	return (mod&ModControl == 0 || h.ebitenKeyIsPressedOrJustReleased(ebiten.KeyControl)) &&
+		(mod&ModShift == 0 || h.ebitenKeyIsPressedOrJustReleased(ebiten.KeyShift)) &&
+		(mod&ModAlt == 0 || h.ebitenKeyIsPressedOrJustReleased(ebiten.KeyAlt)) &&
+		(mod&ModMeta == 0 || h.ebitenKeyIsPressedOrJustReleased(ebiten.KeyMeta))
 }
 
 func (h *Handler) keyIsJustPressed(k Key) bool {
@@ -503,65 +1065,57 @@ func (h *Handler) keyIsJustPressed(k Key) bool {
 		if k.code == int(touchLongTap) {
 			return h.sys.touchHasLongTap
 		}
-		return false
+		return h.touchGestureIsJustPressed(touchCode(k.code))
 	case keyTouchDrag:
 		return h.sys.touchJustHadDrag
+	case keyTouchSwipe, keyMouseSwipe:
+		return h.getKeySwipe(k).code != swipeNone
 	case keyMouseDrag:
-		return h.sys.mouseJustHadDrag
+		return h.mouseDrag(k).justHadDrag
 	case keyGamepad:
 		return h.gamepadKeyIsJustPressed(k)
-	case keyGamepadLeftStick:
-		return h.gamepadStickIsJustPressed(stickCode(k.code), ebiten.StandardGamepadAxisLeftStickHorizontal, ebiten.StandardGamepadAxisLeftStickVertical)
-	case keyGamepadRightStick:
-		return h.gamepadStickIsJustPressed(stickCode(k.code), ebiten.StandardGamepadAxisRightStickHorizontal, ebiten.StandardGamepadAxisRightStickVertical)
 	case keyGamepadStickMotion:
 		return h.gamepadStickMotionIsJustPressed(stickCode(k.code))
 	case keyMouse:
-		return inpututil.IsMouseButtonJustPressed(ebiten.MouseButton(k.code))
-	case keyMouseWithCtrl:
-		return ebiten.IsKeyPressed(ebiten.KeyControl) &&
-			inpututil.IsMouseButtonJustPressed(ebiten.MouseButton(k.code))
-	case keyMouseWithShift:
-		return ebiten.IsKeyPressed(ebiten.KeyShift) &&
-			inpututil.IsMouseButtonJustPressed(ebiten.MouseButton(k.code))
-	case keyMouseWithCtrlShift:
-		return ebiten.IsKeyPressed(ebiten.KeyControl) &&
-			ebiten.IsKeyPressed(ebiten.KeyShift) &&
-			inpututil.IsMouseButtonJustPressed(ebiten.MouseButton(k.code))
-	case keyKeyboardWithCtrl:
-		return ebiten.IsKeyPressed(ebiten.KeyControl) &&
-			inpututil.IsKeyJustPressed(ebiten.Key(k.code))
-	case keyKeyboardWithShift:
-		return ebiten.IsKeyPressed(ebiten.KeyShift) &&
-			inpututil.IsKeyJustPressed(ebiten.Key(k.code))
-	case keyKeyboardWithCtrlShift:
-		return ebiten.IsKeyPressed(ebiten.KeyControl) &&
-			ebiten.IsKeyPressed(ebiten.KeyShift) &&
-			inpututil.IsKeyJustPressed(ebiten.Key(k.code))
+		return h.modifiersArePressed(k.mod) &&
+			h.sys.backend.IsMouseButtonJustPressed(ebiten.MouseButton(k.code))
 	case keyWheel:
-		return h.wheelIsJustPressed(wheelCode(k.code))
-	case keyWheelWithCtrl:
-		return ebiten.IsKeyPressed(ebiten.KeyControl) &&
-			h.wheelIsJustPressed(wheelCode(k.code))
-	case keyWheelWithShift:
-		return ebiten.IsKeyPressed(ebiten.KeyShift) &&
-			h.wheelIsJustPressed(wheelCode(k.code))
-	case keyWheelWithCtrlShift:
-		return ebiten.IsKeyPressed(ebiten.KeyControl) &&
-			ebiten.IsKeyPressed(ebiten.KeyShift) &&
+		return h.modifiersArePressed(k.mod) &&
 			h.wheelIsJustPressed(wheelCode(k.code))
+	case keyChord:
+		return h.chordIsJustPressed(k)
+	case keyGamepadLeftStick, keyGamepadRightStick:
+		return h.gamepadStickIsJustPressed(k)
+	case keyGamepadTrigger, keyTriggerThreshold:
+		st := h.triggerState(k)
+		return st.pressed && !st.prevPressed
+	case keySequence, keyMultiTap, keyHold, keyTap, keyAxis, keyVector2, keyRepeat:
+		return h.keyStateIsJustPressed(k)
 	default:
-		return inpututil.IsKeyJustPressed(ebiten.Key(k.code))
+		return h.modifiersArePressed(k.mod) &&
+			h.sys.backend.IsKeyJustPressed(ebiten.Key(k.code))
 	}
 }
 
 func (h *Handler) getKeyStartPos(k Key) Vec {
 	var result Vec
 	switch k.kind {
+	case keyTouch:
+		if touchCodeIsGesture(touchCode(k.code)) {
+			result = h.sys.touchGesture.startCenter
+		}
 	case keyTouchDrag:
 		result = h.sys.touchStartPos
+	case keyTouchSwipe, keyMouseSwipe:
+		result = h.getKeySwipe(k).startPos
 	case keyMouseDrag:
-		result = h.sys.mouseStartPos
+		result = h.mouseDrag(k).startPos
+	case keyChord:
+		for _, member := range getCompositeKey(k).keys {
+			if keyHasPos(member) {
+				return h.getKeyStartPos(member)
+			}
+		}
 	}
 	return result
 }
@@ -569,42 +1123,128 @@ func (h *Handler) getKeyStartPos(k Key) Vec {
 func (h *Handler) getKeyPos(k Key) Vec {
 	var result Vec
 	switch k.kind {
-	case keyMouse, keyMouseWithCtrl, keyMouseWithShift, keyMouseWithCtrlShift:
+	case keyMouse:
 		result = h.sys.cursorPos
 	case keyTouch:
-		result = h.sys.touchTapPos
+		if touchCodeIsGesture(touchCode(k.code)) {
+			result = h.sys.touchGesture.center
+		} else {
+			result = h.sys.touchTapPos
+		}
 	case keyTouchDrag:
 		result = h.sys.touchDragPos
+	case keyTouchSwipe, keyMouseSwipe:
+		result = h.getKeySwipe(k).endPos
 	case keyMouseDrag:
-		result = h.sys.mouseDragPos
-	case keyWheel, keyWheelWithCtrl, keyWheelWithShift, keyWheelWithCtrlShift:
+		result = h.mouseDrag(k).dragPos
+	case keyWheel:
 		result = h.sys.wheel
 	case keyGamepadStickMotion:
-		axis1, axis2 := h.getStickAxes(stickCode(k.code))
-		result = h.getStickVec(axis1, axis2)
+		result = h.stickVec(stickCode(k.code))
+	case keySequence, keyMultiTap, keyHold, keyTap, keyAxis, keyVector2, keyRepeat:
+		if st := h.keyStates[k]; st != nil {
+			result = st.pos
+		}
+	case keyChord:
+		// Use the first positional key of the chord.
+		for _, member := range getCompositeKey(k).keys {
+			if keyHasPos(member) {
+				return h.getKeyPos(member)
+			}
+		}
 	}
 	return result
 }
 
+// getKeyGestureDeltas returns the two-finger gesture scale and rotation deltas.
+// Both values are zero unless k is a touch gesture key.
+func (h *Handler) getKeyGestureDeltas(k Key) (scale, rotation float64) {
+	if k.kind != keyTouch || !touchCodeIsGesture(touchCode(k.code)) {
+		return 0, 0
+	}
+	g := &h.sys.touchGesture
+	return g.scaleDelta, g.rotationDelta
+}
+
+// mouseDrag returns the drag state of the mouse drag key button.
+func (h *Handler) mouseDrag(k Key) *mouseDragState {
+	return &h.sys.mouseDrags[k.code]
+}
+
+// getKeySwipe returns the swipe info for the swipe keys.
+// The result is zero if k is not a swipe key or its direction doesn't match.
+func (h *Handler) getKeySwipe(k Key) swipeInfo {
+	var swipe swipeInfo
+	switch k.kind {
+	case keyTouchSwipe:
+		swipe = h.sys.touchSwipe
+	case keyMouseSwipe:
+		swipe = h.sys.mouseSwipe
+	}
+	if swipe.code != swipeCode(k.code) {
+		return swipeInfo{}
+	}
+	return swipe
+}
+
+func (h *Handler) touchGestureIsPressed(code touchCode) bool {
+	switch code {
+	case touchPinch:
+		return h.sys.touchGesture.pinching
+	case touchRotate:
+		return h.sys.touchGesture.rotating
+	}
+	return false
+}
+
+func (h *Handler) touchGestureIsJustPressed(code touchCode) bool {
+	switch code {
+	case touchPinch:
+		return h.sys.touchGesture.justPinched
+	case touchRotate:
+		return h.sys.touchGesture.justRotated
+	}
+	return false
+}
+
+func (h *Handler) touchGestureIsJustReleased(code touchCode) bool {
+	switch code {
+	case touchPinch:
+		return h.sys.touchGesture.pinchJustReleased
+	case touchRotate:
+		return h.sys.touchGesture.rotateJustReleased
+	}
+	return false
+}
+
 // getKeyPressDuration returns how long the key has been pressed in ticks same as inpututil.KeyPressDuration.
 // When looking at a key press with modifiers it will return the lowest duration of all key presses.
+// The same rule applies to the chords.
 func (h *Handler) getKeyPressDuration(k Key) int {
-	switch k.kind {
-	case keyKeyboardWithShift:
-		return minOf(inpututil.KeyPressDuration(ebiten.Key(k.code)), inpututil.KeyPressDuration(ebiten.KeyShift))
-	case keyKeyboardWithCtrl:
-		return minOf(inpututil.KeyPressDuration(ebiten.Key(k.code)), inpututil.KeyPressDuration(ebiten.KeyControl))
-	case keyKeyboardWithCtrlShift:
-		return minOf(
-			inpututil.KeyPressDuration(ebiten.Key(k.code)),
-			minOf(
-				inpututil.KeyPressDuration(ebiten.KeyShift),
-				inpututil.KeyPressDuration(ebiten.KeyControl)))
-	case keyKeyboard:
-		return inpututil.KeyPressDuration(ebiten.Key(k.code))
+	if k.kind == keyChord {
+		d := math.MaxInt
+		for _, member := range getCompositeKey(k).keys {
+			d = minOf(d, h.getKeyPressDuration(member))
+		}
+		return d
 	}
-
-	return 0
+	if k.kind != keyKeyboard {
+		return 0
+	}
+	d := h.sys.backend.KeyPressDuration(ebiten.Key(k.code))
+	if k.mod&ModControl != 0 {
+		d = minOf(d, h.sys.backend.KeyPressDuration(ebiten.KeyControl))
+	}
+	if k.mod&ModShift != 0 {
+		d = minOf(d, h.sys.backend.KeyPressDuration(ebiten.KeyShift))
+	}
+	if k.mod&ModAlt != 0 {
+		d = minOf(d, h.sys.backend.KeyPressDuration(ebiten.KeyAlt))
+	}
+	if k.mod&ModMeta != 0 {
+		d = minOf(d, h.sys.backend.KeyPressDuration(ebiten.KeyMeta))
+	}
+	return d
 }
 
 func (h *Handler) keyIsPressed(k Key) bool {
@@ -616,49 +1256,107 @@ func (h *Handler) keyIsPressed(k Key) bool {
 		if k.code == int(touchLongTap) {
 			return h.sys.touchHasLongTap
 		}
-		return false
+		return h.touchGestureIsPressed(touchCode(k.code))
 	case keyTouchDrag:
 		return h.sys.touchHasDrag
+	case keyTouchSwipe, keyMouseSwipe:
+		return h.getKeySwipe(k).code != swipeNone
 	case keyMouseDrag:
-		return h.sys.mouseHasDrag
+		return h.mouseDrag(k).hasDrag
 	case keyGamepad:
 		return h.gamepadKeyIsPressed(k)
-	case keyGamepadLeftStick:
-		return h.gamepadStickIsPressed(stickCode(k.code), ebiten.StandardGamepadAxisLeftStickHorizontal, ebiten.StandardGamepadAxisLeftStickVertical)
-	case keyGamepadRightStick:
-		return h.gamepadStickIsPressed(stickCode(k.code), ebiten.StandardGamepadAxisRightStickHorizontal, ebiten.StandardGamepadAxisRightStickVertical)
 	case keyGamepadStickMotion:
 		return h.gamepadStickMotionIsPressed(stickCode(k.code))
 	case keyMouse:
-		return ebiten.IsMouseButtonPressed(ebiten.MouseButton(k.code))
-	case keyMouseWithCtrl:
-		return ebiten.IsKeyPressed(ebiten.KeyControl) &&
-			ebiten.IsMouseButtonPressed(ebiten.MouseButton(k.code))
-	case keyMouseWithShift:
-		return ebiten.IsKeyPressed(ebiten.KeyShift) &&
-			ebiten.IsMouseButtonPressed(ebiten.MouseButton(k.code))
-	case keyMouseWithCtrlShift:
-		return ebiten.IsKeyPressed(ebiten.KeyControl) &&
-			ebiten.IsKeyPressed(ebiten.KeyShift) &&
-			ebiten.IsMouseButtonPressed(ebiten.MouseButton(k.code))
-	case keyKeyboardWithCtrl:
-		return ebiten.IsKeyPressed(ebiten.KeyControl) &&
-			ebiten.IsKeyPressed(ebiten.Key(k.code))
-	case keyKeyboardWithShift:
-		return ebiten.IsKeyPressed(ebiten.KeyShift) &&
-			ebiten.IsKeyPressed(ebiten.Key(k.code))
-	case keyKeyboardWithCtrlShift:
-		return ebiten.IsKeyPressed(ebiten.KeyControl) &&
-			ebiten.IsKeyPressed(ebiten.KeyShift) &&
-			ebiten.IsKeyPressed(ebiten.Key(k.code))
+		return h.modifiersArePressed(k.mod) &&
+			h.sys.backend.IsMouseButtonPressed(ebiten.MouseButton(k.code))
+	case keyWheel:
+		// Wheel keys have no constantly pressed state,
+		// they're pressed during the frames when the wheel moves.
+		return h.modifiersArePressed(k.mod) &&
+			h.wheelIsJustPressed(wheelCode(k.code))
+	case keyChord:
+		return h.chordIsPressed(k)
+	case keySequence, keyMultiTap, keyTap:
+		return h.keyStateIsJustPressed(k)
+	case keyGamepadLeftStick, keyGamepadRightStick:
+		return h.gamepadStickIsPressed(k)
+	case keyGamepadTrigger, keyTriggerThreshold:
+		return h.triggerState(k).pressed
+	case keyHold, keyAxis, keyVector2, keyRepeat:
+		st := h.keyStates[k]
+		return st != nil && st.pressed
 	default:
-		return ebiten.IsKeyPressed(ebiten.Key(k.code))
+		return h.modifiersArePressed(k.mod) &&
+			h.sys.backend.IsKeyPressed(ebiten.Key(k.code))
+	}
+}
+
+// keyIsSuppressed reports whether k should be ignored due to
+// a more specific key being active (see StrictModifiers).
+// For the released keys, a just released specific key suppresses k too.
+func (h *Handler) keyIsSuppressed(k Key, released bool) bool {
+	if !h.StrictModifiers {
+		return false
+	}
+	if h.specificKeys == nil {
+		h.specificKeys = collectSpecificKeys(h.keymap)
+	}
+	for _, specific := range h.specificKeys[k] {
+		if h.keyIsPressed(specific) {
+			return true
+		}
+		if released && h.keyIsJustReleased(specific) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) chordIsPressed(k Key) bool {
+	for _, member := range getCompositeKey(k).keys {
+		if !h.keyIsPressed(member) {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *Handler) chordIsJustPressed(k Key) bool {
+	// The chord is just pressed when its last key arrives:
+	// all keys are pressed and at least one of them is just pressed.
+	justPressed := false
+	for _, member := range getCompositeKey(k).keys {
+		if !h.keyIsPressed(member) {
+			return false
+		}
+		if !justPressed && h.keyIsJustPressed(member) {
+			justPressed = true
+		}
 	}
+	return justPressed
+}
+
+func (h *Handler) chordIsJustReleased(k Key) bool {
+	// The chord was pressed during the previous frame if all of its keys
+	// were pressed back then: they're either pressed, but not just pressed,
+	// or they're just released.
+	justReleased := false
+	for _, member := range getCompositeKey(k).keys {
+		if h.keyIsJustReleased(member) {
+			justReleased = true
+			continue
+		}
+		if !h.keyIsPressed(member) || h.keyIsJustPressed(member) {
+			return false
+		}
+	}
+	return justReleased
 }
 
 func (h *Handler) eventSliceFind(slice []simulatedEvent, k Key) int {
 	for i, e := range slice {
-		if e.code == k.code && e.keyKind == k.kind {
+		if e.code == k.code && e.keyKind == k.kind && e.keyMod == k.mod {
 			if keyNeedID(e.keyKind) && e.playerID != h.id {
 				continue
 			}
@@ -681,13 +1379,30 @@ func (h *Handler) pressedSimulatedKeyInfo(justPressed bool, k Key) (EventInfo, b
 		}
 		info.Pos = h.sys.simulatedEvents[i].pos
 		info.StartPos = h.sys.simulatedEvents[i].startPos
-		info.kind = k.kind
-		info.hasPos = keyHasPos(k.kind)
+		info.key = k
+		info.hasPos = keyHasPos(k)
 		return info, bool3true
 	}
 	return info, bool3unset
 }
 
+func (h *Handler) releasedSimulatedKeyInfo(k Key) (EventInfo, bool) {
+	var info EventInfo
+	i := h.eventSliceFind(h.sys.prevSimulatedEvents, k)
+	if i == -1 || h.eventSliceContains(h.sys.simulatedEvents, k) {
+		return info, false
+	}
+	if k.kind != keySimulated && h.keyIsPressed(k) {
+		// The real input device still holds this key down.
+		return info, false
+	}
+	info.Pos = h.sys.prevSimulatedEvents[i].pos
+	info.StartPos = h.sys.prevSimulatedEvents[i].startPos
+	info.key = k
+	info.hasPos = keyHasPos(k)
+	return info, true
+}
+
 func (h *Handler) simulatedKeyIsPressed(k Key) bool {
 	return h.eventSliceContains(h.sys.simulatedEvents, k)
 }
@@ -718,21 +1433,33 @@ func (h *Handler) wheelIsJustPressed(code wheelCode) bool {
 		return h.sys.wheel.Y < 0
 	case wheelVertical:
 		return h.sys.wheel.Y != 0
+	case wheelRight:
+		return h.sys.wheel.X > 0
+	case wheelLeft:
+		return h.sys.wheel.X < 0
+	case wheelHorizontal:
+		return h.sys.wheel.X != 0
 	default:
 		return false
 	}
 }
 
 func (h *Handler) gamepadKeyIsJustReleased(k Key) bool {
+	if h.virtualButtonIsJustReleased(k) {
+		return true
+	}
 	if h.gamepadInfo().model == gamepadStandard {
-		return inpututil.IsStandardGamepadButtonJustReleased(ebiten.GamepadID(h.id), ebiten.StandardGamepadButton(k.code))
+		return h.sys.backend.IsStandardGamepadButtonJustReleased(ebiten.GamepadID(h.id), ebiten.StandardGamepadButton(k.code))
 	}
-	return inpututil.IsGamepadButtonJustReleased(ebiten.GamepadID(h.id), h.mappedGamepadKey(k.code))
+	return h.sys.backend.IsGamepadButtonJustReleased(ebiten.GamepadID(h.id), h.mappedGamepadKey(k.code))
 }
 
 func (h *Handler) gamepadKeyIsJustPressed(k Key) bool {
+	if h.virtualButtonIsJustPressed(k) {
+		return true
+	}
 	if h.gamepadInfo().model == gamepadStandard {
-		return inpututil.IsStandardGamepadButtonJustPressed(ebiten.GamepadID(h.id), ebiten.StandardGamepadButton(k.code))
+		return h.sys.backend.IsStandardGamepadButtonJustPressed(ebiten.GamepadID(h.id), ebiten.StandardGamepadButton(k.code))
 	}
 	if h.gamepadInfo().model == gamepadFirefoxXinput {
 		if isDPadButton(k.code) {
@@ -748,12 +1475,15 @@ func (h *Handler) gamepadKeyIsJustPressed(k Key) bool {
 				h.bumperIsActive(h.gamepadInfo().axisValues[5])
 		}
 	}
-	return inpututil.IsGamepadButtonJustPressed(ebiten.GamepadID(h.id), h.mappedGamepadKey(k.code))
+	return h.sys.backend.IsGamepadButtonJustPressed(ebiten.GamepadID(h.id), h.mappedGamepadKey(k.code))
 }
 
 func (h *Handler) gamepadKeyIsPressed(k Key) bool {
+	if h.virtualButtonIsPressed(k) {
+		return true
+	}
 	if h.gamepadInfo().model == gamepadStandard {
-		return ebiten.IsStandardGamepadButtonPressed(ebiten.GamepadID(h.id), ebiten.StandardGamepadButton(k.code))
+		return h.sys.backend.IsStandardGamepadButtonPressed(ebiten.GamepadID(h.id), ebiten.StandardGamepadButton(k.code))
 	}
 	if h.gamepadInfo().model == gamepadFirefoxXinput {
 		if isDPadButton(k.code) {
@@ -766,36 +1496,7 @@ func (h *Handler) gamepadKeyIsPressed(k Key) bool {
 			return h.bumperIsActive(h.gamepadInfo().axisValues[5])
 		}
 	}
-	return ebiten.IsGamepadButtonPressed(ebiten.GamepadID(h.id), h.mappedGamepadKey(k.code))
-}
-
-func (h *Handler) gamepadStickIsActive(code stickCode, vec Vec) bool {
-	if vecLen(vec) < 0.5 {
-		return false
-	}
-	// Allow some axis overlap to emulate double direction pressing,
-	// like with D-pad buttons.
-	const overlap float64 = math.Pi / 7
-	switch code {
-	case stickUp:
-		angle := angleNormalized(vecAngle(vec))
-		return angle > (math.Pi+math.Pi/4)-overlap && angle <= (2*math.Pi-math.Pi/4)+overlap
-	case stickRight:
-		angle := angleNormalized(vecAngle(vec))
-		return angle <= (math.Pi/4)+overlap || angle > (2*math.Pi-math.Pi/4)-overlap
-	case stickDown:
-		angle := angleNormalized(vecAngle(vec))
-		return angle > (math.Pi/4)-overlap && angle <= (math.Pi-math.Pi/4)+overlap
-	case stickLeft:
-		angle := angleNormalized(vecAngle(vec))
-		return angle > (math.Pi-math.Pi/4)-overlap && angle <= (math.Pi+math.Pi/4)+overlap
-	}
-	return false
-}
-
-func (h *Handler) gamepadStickIsJustPressed(code stickCode, axis1, axis2 ebiten.StandardGamepadAxis) bool {
-	return !h.gamepadStickIsActive(code, h.getStickPrevVec(int(axis1), int(axis2))) &&
-		h.gamepadStickIsActive(code, h.getStickVec(int(axis1), int(axis2)))
+	return h.sys.backend.IsGamepadButtonPressed(ebiten.GamepadID(h.id), h.mappedGamepadKey(k.code))
 }
 
 func (h *Handler) getStickAxes(code stickCode) (int, int) {
@@ -822,23 +1523,53 @@ func (h *Handler) getStickAxes(code stickCode) (int, int) {
 }
 
 func (h *Handler) gamepadStickMotionIsJustPressed(code stickCode) bool {
-	return !h.gamepadStickMotionIsActive(h.getStickPrevVec(h.getStickAxes(code))) &&
-		h.gamepadStickMotionIsActive(h.getStickVec(h.getStickAxes(code)))
+	return !h.gamepadStickMotionIsActive(code, h.stickRawPrevVec(code)) &&
+		h.gamepadStickMotionIsActive(code, h.stickRawVec(code))
 }
 
 func (h *Handler) gamepadStickMotionIsPressed(code stickCode) bool {
-	return h.gamepadStickMotionIsActive(h.getStickVec(h.getStickAxes(code)))
+	return h.gamepadStickMotionIsActive(code, h.stickRawVec(code))
 }
 
-func (h *Handler) gamepadStickMotionIsActive(vec Vec) bool {
+// gamepadStickMotionIsActive reports whether the raw stick position is outside of its deadzone.
+func (h *Handler) gamepadStickMotionIsActive(stick stickCode, raw Vec) bool {
+	config := h.stickConfig(stick)
+	// The check is done before the response curve is applied,
+	// so the curve doesn't change the activation point.
+	if config.Deadzone != DeadzoneDefault {
+		return applyStickDeadzone(config, h.GamepadDeadzone, raw) != Vec{}
+	}
 	// Some gamepads could register a slight movement all the time,
 	// even if the stick is in its home position.
-	return math.Abs(vec.X)+math.Abs(vec.Y) >= h.GamepadDeadzone
+	return math.Abs(raw.X)+math.Abs(raw.Y) >= stickInnerDeadzone(config, h.GamepadDeadzone)
+}
+
+// stickVec returns the stickLeft or stickRight stick position.
+// An active virtual stick takes priority over the real one.
+// The stick config is applied to the position.
+func (h *Handler) stickVec(stick stickCode) Vec {
+	return applyStickConfig(h.stickConfig(stick), h.GamepadDeadzone, h.stickRawVec(stick))
+}
+
+// stickPrevVec is like stickVec, but it returns the previous frame position.
+func (h *Handler) stickPrevVec(stick stickCode) Vec {
+	return applyStickConfig(h.stickConfig(stick), h.GamepadDeadzone, h.stickRawPrevVec(stick))
 }
 
-func (h *Handler) gamepadStickIsPressed(code stickCode, axis1, axis2 ebiten.StandardGamepadAxis) bool {
-	vec := h.getStickVec(int(axis1), int(axis2))
-	return h.gamepadStickIsActive(code, vec)
+// stickRawVec is like stickVec, but the stick config is not applied.
+func (h *Handler) stickRawVec(stick stickCode) Vec {
+	if vs := h.virtualStick(stick); vs != nil && vs.active {
+		return vs.value
+	}
+	return h.getStickVec(h.getStickAxes(stick))
+}
+
+// stickRawPrevVec is like stickPrevVec, but the stick config is not applied.
+func (h *Handler) stickRawPrevVec(stick stickCode) Vec {
+	if vs := h.virtualStick(stick); vs != nil && vs.prevActive {
+		return vs.prevValue
+	}
+	return h.getStickPrevVec(h.getStickAxes(stick))
 }
 
 func (h *Handler) getStickPrevVec(axis1, axis2 int) Vec {
@@ -871,6 +1602,6 @@ func (h *Handler) mappedGamepadKey(keyCode int) ebiten.GamepadButton {
 	}
 }
 
-func (h *Handler) updateLastDevice(kind keyKind) {
-	h.last = kind.device()
+func (h *Handler) updateLastDevice(k Key) {
+	h.last = keyDevice(k)
 }
@@ -0,0 +1,2161 @@
+package input_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	input "github.com/quasilyte/ebitengine-input"
+	"github.com/quasilyte/ebitengine-input/inputtest"
+)
+
+const (
+	actionUnknown input.Action = iota
+	actionRun
+	actionCharge
+	actionNoKeys
+)
+
+func newTestHandler(keymap input.Keymap) (*input.System, *input.Handler, *inputtest.Backend) {
+	b := inputtest.NewBackend()
+	sys := &input.System{}
+	sys.Init(input.SystemConfig{
+		DevicesEnabled: input.AnyDevice,
+		Backend:        b,
+	})
+	return sys, sys.NewHandler(0, keymap), b
+}
+
+type actionState struct {
+	justPressed  bool
+	pressed      bool
+	justReleased bool
+}
+
+func getActionState(h *input.Handler, a input.Action) actionState {
+	return actionState{
+		justPressed:  h.ActionIsJustPressed(a),
+		pressed:      h.ActionIsPressed(a),
+		justReleased: h.ActionIsJustReleased(a),
+	}
+}
+
+func TestSimulatedHold(t *testing.T) {
+	sys, h, b := newTestHandler(input.Keymap{
+		actionRun:    {input.KeyShift},
+		actionCharge: {input.KeyGamepadX},
+		actionNoKeys: {},
+	})
+	b.ConnectGamepad(0, "test gamepad")
+
+	type step struct {
+		emit func()
+		want actionState
+	}
+	tests := []struct {
+		name   string
+		action input.Action
+		steps  []step
+	}{
+		{
+			name:   "key",
+			action: actionRun,
+			steps: []step{
+				{func() { h.EmitKeyHold(input.SimulatedKeyEvent{Key: input.KeyShift}) }, actionState{justPressed: true, pressed: true}},
+				{nil, actionState{pressed: true}},
+				{nil, actionState{pressed: true}},
+				{func() { h.EmitKeyRelease(input.KeyShift) }, actionState{justReleased: true}},
+				{nil, actionState{}},
+			},
+		},
+		{
+			name:   "gamepad key",
+			action: actionCharge,
+			steps: []step{
+				{func() { h.EmitKeyHold(input.SimulatedKeyEvent{Key: input.KeyGamepadX}) }, actionState{justPressed: true, pressed: true}},
+				{func() { h.EmitKeyHold(input.SimulatedKeyEvent{Key: input.KeyGamepadX}) }, actionState{pressed: true}},
+				{func() { h.EmitKeyRelease(input.KeyGamepadX) }, actionState{justReleased: true}},
+				{nil, actionState{}},
+			},
+		},
+		{
+			name:   "action",
+			action: actionNoKeys,
+			steps: []step{
+				{func() { h.EmitHold(input.SimulatedAction{Action: actionNoKeys}) }, actionState{justPressed: true, pressed: true}},
+				{nil, actionState{pressed: true}},
+				{func() { h.EmitRelease(actionNoKeys) }, actionState{justReleased: true}},
+				{nil, actionState{}},
+			},
+		},
+		{
+			name:   "single frame event",
+			action: actionRun,
+			steps: []step{
+				{func() { h.EmitKeyEvent(input.SimulatedKeyEvent{Key: input.KeyShift}) }, actionState{justPressed: true, pressed: true}},
+				{nil, actionState{justReleased: true}},
+				{nil, actionState{}},
+			},
+		},
+		{
+			name:   "hold and release during the same frame",
+			action: actionRun,
+			steps: []step{
+				{func() {
+					h.EmitKeyHold(input.SimulatedKeyEvent{Key: input.KeyShift})
+					h.EmitKeyRelease(input.KeyShift)
+				}, actionState{}},
+			},
+		},
+		{
+			name:   "release without hold",
+			action: actionNoKeys,
+			steps: []step{
+				{func() { h.EmitRelease(actionNoKeys) }, actionState{}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		for i, s := range test.steps {
+			if s.emit != nil {
+				s.emit()
+			}
+			sys.Update()
+			have := getActionState(h, test.action)
+			if have != s.want {
+				t.Fatalf("%s: step[%d]:\nhave: %+v\nwant: %+v", test.name, i, have, s.want)
+			}
+		}
+	}
+}
+
+func TestSimulatedHoldWithRealKey(t *testing.T) {
+	sys, h, b := newTestHandler(input.Keymap{
+		actionRun: {input.KeyShift},
+	})
+
+	b.PressKey(ebiten.KeyShiftLeft)
+	h.EmitKeyHold(input.SimulatedKeyEvent{Key: input.KeyShift})
+	sys.Update()
+	h.EmitKeyRelease(input.KeyShift)
+	sys.Update()
+	if h.ActionIsJustReleased(actionRun) {
+		t.Fatal("simulated release is reported while the real key is pressed")
+	}
+	if !h.ActionIsPressed(actionRun) {
+		t.Fatal("action is not pressed while the real key is pressed")
+	}
+}
+
+func TestSimulatedHoldInfo(t *testing.T) {
+	sys, h, _ := newTestHandler(input.Keymap{
+		actionRun: {input.KeyMouseLeft},
+	})
+
+	h.EmitKeyHold(input.SimulatedKeyEvent{Key: input.KeyMouseLeft, Pos: input.Vec{X: 1, Y: 2}})
+	sys.Update()
+	if info, ok := h.JustPressedActionInfo(actionRun); !ok || info.Pos != (input.Vec{X: 1, Y: 2}) {
+		t.Fatalf("unexpected just pressed info: %v (ok=%v)", info.Pos, ok)
+	}
+
+	h.EmitKeyHold(input.SimulatedKeyEvent{Key: input.KeyMouseLeft, Pos: input.Vec{X: 3, Y: 4}})
+	sys.Update()
+	if _, ok := h.JustPressedActionInfo(actionRun); ok {
+		t.Fatal("updated hold is reported as just pressed")
+	}
+	if info, ok := h.PressedActionInfo(actionRun); !ok || info.Pos != (input.Vec{X: 3, Y: 4}) {
+		t.Fatalf("unexpected pressed info: %v (ok=%v)", info.Pos, ok)
+	}
+
+	h.EmitKeyRelease(input.KeyMouseLeft)
+	sys.Update()
+	info, ok := h.JustReleasedActionInfo(actionRun)
+	if !ok {
+		t.Fatal("action is not just released")
+	}
+	if info.Pos != (input.Vec{X: 3, Y: 4}) {
+		t.Fatalf("unexpected just released pos: %v", info.Pos)
+	}
+}
+
+func TestKeyModifiers(t *testing.T) {
+	const (
+		actionSave input.Action = iota + 1
+		actionClose
+		actionSelect
+	)
+	sys, h, b := newTestHandler(input.Keymap{
+		actionSave:   {input.KeyWithModifier(input.KeyS, input.ModMeta)},
+		actionClose:  {input.KeyWithModifier(input.KeyF4, input.ModAlt)},
+		actionSelect: {input.KeyWithModifier(input.KeyMouseLeft, input.ModControl|input.ModAlt)},
+	})
+
+	b.PressKey(ebiten.KeyS)
+	sys.Update()
+	if h.ActionIsPressed(actionSave) {
+		t.Fatal("meta+s is activated without meta")
+	}
+	b.PressKey(ebiten.KeyMetaLeft)
+	sys.Update()
+	if h.ActionIsJustPressed(actionSave) {
+		t.Fatal("meta+s is just pressed when only meta was just pressed")
+	}
+	b.ReleaseKey(ebiten.KeyS)
+	sys.Update()
+	if !h.ActionIsJustReleased(actionSave) {
+		t.Fatal("meta+s is not just released")
+	}
+	b.PressKey(ebiten.KeyS)
+	sys.Update()
+	info, ok := h.JustPressedActionInfo(actionSave)
+	if !ok {
+		t.Fatal("meta+s is not just pressed")
+	}
+	if info.Source() != input.KeyboardDevice {
+		t.Fatalf("unexpected meta+s source: %s", info.Source())
+	}
+	b.ReleaseKey(ebiten.KeyS)
+	b.ReleaseKey(ebiten.KeyMetaLeft)
+	sys.Update()
+
+	b.PressKey(ebiten.KeyAltRight)
+	b.PressKey(ebiten.KeyF4)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionClose) {
+		t.Fatal("alt+f4 is not just pressed")
+	}
+	b.ReleaseKey(ebiten.KeyF4)
+	sys.Update()
+
+	b.PressKey(ebiten.KeyControlLeft)
+	b.PressMouseButton(ebiten.MouseButtonLeft)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionSelect) {
+		t.Fatal("ctrl+alt+click is not just pressed")
+	}
+	if h.LastDevice() != input.KeyboardDevice|input.MouseDevice {
+		t.Fatalf("unexpected last device: %s", h.LastDevice())
+	}
+	b.ReleaseKey(ebiten.KeyAltRight)
+	sys.Update()
+	if h.ActionIsPressed(actionSelect) {
+		t.Fatal("ctrl+alt+click is pressed after alt is released")
+	}
+}
+
+func TestKeyChord(t *testing.T) {
+	const (
+		actionSpecial input.Action = iota + 1
+		actionSwap
+		actionInspect
+	)
+	sys, h, b := newTestHandler(input.Keymap{
+		actionSpecial: {input.KeyChord(input.KeyGamepadL1, input.KeyGamepadA)},
+		actionSwap:    {input.KeyChord(input.KeyQ, input.KeyE)},
+		actionInspect: {input.KeyChord(input.KeyShift, input.KeyMouseRight)},
+	})
+	b.ConnectGamepad(0, "test gamepad")
+
+	type step struct {
+		input func()
+		want  actionState
+	}
+	tests := []struct {
+		name   string
+		action input.Action
+		steps  []step
+	}{
+		{
+			name:   "gamepad",
+			action: actionSpecial,
+			steps: []step{
+				{func() { b.PressGamepadButton(0, ebiten.StandardGamepadButtonFrontTopLeft) }, actionState{}},
+				{nil, actionState{}},
+				{func() { b.PressGamepadButton(0, ebiten.StandardGamepadButtonRightBottom) }, actionState{justPressed: true, pressed: true}},
+				{nil, actionState{pressed: true}},
+				{func() { b.ReleaseGamepadButton(0, ebiten.StandardGamepadButtonFrontTopLeft) }, actionState{justReleased: true}},
+				{func() { b.ReleaseGamepadButton(0, ebiten.StandardGamepadButtonRightBottom) }, actionState{}},
+			},
+		},
+		{
+			name:   "keyboard reversed order",
+			action: actionSwap,
+			steps: []step{
+				{func() { b.PressKey(ebiten.KeyE) }, actionState{}},
+				{func() { b.PressKey(ebiten.KeyQ) }, actionState{justPressed: true, pressed: true}},
+				{func() {
+					b.ReleaseKey(ebiten.KeyQ)
+					b.ReleaseKey(ebiten.KeyE)
+				}, actionState{justReleased: true}},
+				{nil, actionState{}},
+			},
+		},
+		{
+			name:   "keyboard simultaneous press",
+			action: actionSwap,
+			steps: []step{
+				{func() {
+					b.PressKey(ebiten.KeyQ)
+					b.PressKey(ebiten.KeyE)
+				}, actionState{justPressed: true, pressed: true}},
+				{func() { b.ReleaseKey(ebiten.KeyE) }, actionState{justReleased: true}},
+				{func() { b.PressKey(ebiten.KeyE) }, actionState{justPressed: true, pressed: true}},
+				{func() {
+					b.ReleaseKey(ebiten.KeyQ)
+					b.ReleaseKey(ebiten.KeyE)
+				}, actionState{justReleased: true}},
+			},
+		},
+		{
+			name:   "press and release during the same frame",
+			action: actionSwap,
+			steps: []step{
+				{func() { b.PressKey(ebiten.KeyQ) }, actionState{}},
+				{func() {
+					b.PressKey(ebiten.KeyE)
+					b.ReleaseKey(ebiten.KeyQ)
+				}, actionState{}},
+				{func() { b.ReleaseKey(ebiten.KeyE) }, actionState{}},
+			},
+		},
+		{
+			name:   "keyboard and mouse",
+			action: actionInspect,
+			steps: []step{
+				{func() { b.PressMouseButton(ebiten.MouseButtonRight) }, actionState{}},
+				{func() { b.PressKey(ebiten.KeyShiftRight) }, actionState{justPressed: true, pressed: true}},
+				{func() { b.ReleaseMouseButton(ebiten.MouseButtonRight) }, actionState{justReleased: true}},
+				{func() { b.ReleaseKey(ebiten.KeyShiftRight) }, actionState{}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		for i, s := range test.steps {
+			if s.input != nil {
+				s.input()
+			}
+			sys.Update()
+			have := getActionState(h, test.action)
+			if have != s.want {
+				t.Fatalf("%s: step[%d]:\nhave: %+v\nwant: %+v", test.name, i, have, s.want)
+			}
+		}
+	}
+}
+
+func TestKeyChordInfo(t *testing.T) {
+	sys, h, b := newTestHandler(input.Keymap{
+		actionRun: {input.KeyChord(input.KeyShift, input.KeyMouseRight)},
+	})
+
+	b.MoveCursor(10, 20)
+	b.PressKey(ebiten.KeyShiftLeft)
+	b.PressMouseButton(ebiten.MouseButtonRight)
+	sys.Update()
+	info, ok := h.JustPressedActionInfo(actionRun)
+	if !ok {
+		t.Fatal("chord is not just pressed")
+	}
+	if !info.HasPos() || info.Pos != (input.Vec{X: 10, Y: 20}) {
+		t.Fatalf("unexpected chord pos: %v", info.Pos)
+	}
+	if info.Source() != input.KeyboardDevice|input.MouseDevice {
+		t.Fatalf("unexpected chord source: %s", info.Source())
+	}
+	if h.LastDevice() != input.KeyboardDevice|input.MouseDevice {
+		t.Fatalf("unexpected last device: %s", h.LastDevice())
+	}
+
+	names := h.ActionKeyNames(actionRun, input.KeyboardDevice|input.MouseDevice)
+	if len(names) != 1 || names[0] != "shift+mouse_right_button" {
+		t.Fatalf("unexpected key names: %v", names)
+	}
+	if names := h.ActionKeyNames(actionRun, input.MouseDevice); len(names) != 0 {
+		t.Fatalf("unexpected key names for the mouse-only mask: %v", names)
+	}
+
+	h.EmitKeyEvent(input.SimulatedKeyEvent{
+		Key: input.KeyChord(input.KeyShift, input.KeyMouseRight),
+		Pos: input.Vec{X: 5, Y: 5},
+	})
+	b.ReleaseMouseButton(ebiten.MouseButtonRight)
+	sys.Update()
+	info, ok = h.PressedActionInfo(actionRun)
+	if !ok || info.Pos != (input.Vec{X: 5, Y: 5}) {
+		t.Fatalf("simulated chord is not pressed: %v (ok=%v)", info.Pos, ok)
+	}
+}
+
+func TestStrictModifiers(t *testing.T) {
+	const (
+		actionSave input.Action = iota + 1
+		actionSaveAs
+		actionMoveDown
+		actionJump
+		actionSpecial
+	)
+	keymap := input.Keymap{
+		actionSave:     {input.KeyWithModifier(input.KeyS, input.ModControl)},
+		actionSaveAs:   {input.KeyWithModifier(input.KeyS, input.ModControlShift)},
+		actionMoveDown: {input.KeyS, input.KeyDown},
+		actionJump:     {input.KeyGamepadA},
+		actionSpecial:  {input.KeyChord(input.KeyGamepadL1, input.KeyGamepadA)},
+	}
+
+	type frameState struct {
+		save     actionState
+		saveAs   actionState
+		moveDown actionState
+	}
+	getFrameState := func(h *input.Handler) frameState {
+		return frameState{
+			save:     getActionState(h, actionSave),
+			saveAs:   getActionState(h, actionSaveAs),
+			moveDown: getActionState(h, actionMoveDown),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		strict bool
+		want   []frameState
+	}{
+		{
+			name:   "strict",
+			strict: true,
+			want: []frameState{
+				{},
+				{save: actionState{justPressed: true, pressed: true}},
+				{saveAs: actionState{justPressed: true, pressed: true}},
+				{saveAs: actionState{justReleased: true}},
+				{moveDown: actionState{justPressed: true, pressed: true}},
+				{moveDown: actionState{justReleased: true}},
+			},
+		},
+		{
+			name:   "default",
+			strict: false,
+			want: []frameState{
+				{},
+				{save: actionState{justPressed: true, pressed: true}, moveDown: actionState{justPressed: true, pressed: true}},
+				{
+					save:     actionState{justPressed: true, pressed: true},
+					saveAs:   actionState{justPressed: true, pressed: true},
+					moveDown: actionState{justPressed: true, pressed: true},
+				},
+				{
+					save:     actionState{justReleased: true},
+					saveAs:   actionState{justReleased: true},
+					moveDown: actionState{justReleased: true},
+				},
+				{moveDown: actionState{justPressed: true, pressed: true}},
+				{moveDown: actionState{justReleased: true}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		sys, h, b := newTestHandler(keymap)
+		h.StrictModifiers = test.strict
+		inputs := []func(){
+			func() { b.PressKey(ebiten.KeyControlLeft) },
+			func() { b.PressKey(ebiten.KeyS) },
+			func() {
+				b.ReleaseKey(ebiten.KeyS)
+				b.PressKey(ebiten.KeyShiftLeft)
+				sys.Update()
+				b.PressKey(ebiten.KeyS)
+			},
+			func() { b.ReleaseKey(ebiten.KeyS) },
+			func() {
+				b.ReleaseKey(ebiten.KeyControlLeft)
+				b.ReleaseKey(ebiten.KeyShiftLeft)
+				b.PressKey(ebiten.KeyS)
+			},
+			func() { b.ReleaseKey(ebiten.KeyS) },
+		}
+		for i, f := range inputs {
+			f()
+			sys.Update()
+			have := getFrameState(h)
+			if have != test.want[i] {
+				t.Fatalf("%s: frame[%d]:\nhave: %+v\nwant: %+v", test.name, i, have, test.want[i])
+			}
+		}
+	}
+
+	t.Run("chord", func(t *testing.T) {
+		sys, h, b := newTestHandler(keymap)
+		h.StrictModifiers = true
+		b.ConnectGamepad(0, "test gamepad")
+		b.PressGamepadButton(0, ebiten.StandardGamepadButtonRightBottom)
+		sys.Update()
+		if !h.ActionIsJustPressed(actionJump) {
+			t.Fatal("a is not just pressed")
+		}
+		b.ReleaseGamepadButton(0, ebiten.StandardGamepadButtonRightBottom)
+		sys.Update()
+
+		b.PressGamepadButton(0, ebiten.StandardGamepadButtonFrontTopLeft)
+		b.PressGamepadButton(0, ebiten.StandardGamepadButtonRightBottom)
+		sys.Update()
+		if !h.ActionIsJustPressed(actionSpecial) {
+			t.Fatal("l1+a is not just pressed")
+		}
+		if h.ActionIsPressed(actionJump) {
+			t.Fatal("a is not suppressed by l1+a")
+		}
+		b.ReleaseGamepadButton(0, ebiten.StandardGamepadButtonFrontTopLeft)
+		b.ReleaseGamepadButton(0, ebiten.StandardGamepadButtonRightBottom)
+		sys.Update()
+		if !h.ActionIsJustReleased(actionSpecial) {
+			t.Fatal("l1+a is not just released")
+		}
+		if h.ActionIsJustReleased(actionJump) {
+			t.Fatal("a release is not suppressed by l1+a")
+		}
+
+		h.Remap(input.Keymap{actionJump: {input.KeyGamepadA}})
+		b.PressGamepadButton(0, ebiten.StandardGamepadButtonFrontTopLeft)
+		b.PressGamepadButton(0, ebiten.StandardGamepadButtonRightBottom)
+		sys.Update()
+		if !h.ActionIsPressed(actionJump) {
+			t.Fatal("a is suppressed by a chord that is not in the keymap")
+		}
+	})
+}
+
+func TestKeySequence(t *testing.T) {
+	const (
+		actionCheat input.Action = iota + 1
+		actionCombo
+	)
+	cheat := input.KeySequence(30,
+		input.KeyUp, input.KeyUp, input.KeyDown, input.KeyDown,
+		input.KeyLeft, input.KeyRight, input.KeyB, input.KeyA)
+	combo := input.KeySequenceSteps(
+		input.SequenceStep{Key: input.KeyGamepadX},
+		input.SequenceStep{Key: input.KeyGamepadX, Window: 10},
+		input.SequenceStep{Key: input.KeyGamepadY, Window: 5},
+	)
+	if cheat.String() != "up,up,down,down,left,right,b,a" {
+		t.Fatalf("unexpected sequence name: %q", cheat.String())
+	}
+	if input.KeySequence(5, input.KeyA, input.KeyB) == input.KeySequence(6, input.KeyA, input.KeyB) {
+		t.Fatal("sequences with different windows are equal")
+	}
+
+	keyboard := map[input.Key]ebiten.Key{
+		input.KeyUp:    ebiten.KeyUp,
+		input.KeyDown:  ebiten.KeyDown,
+		input.KeyLeft:  ebiten.KeyLeft,
+		input.KeyRight: ebiten.KeyRight,
+		input.KeyA:     ebiten.KeyA,
+		input.KeyB:     ebiten.KeyB,
+		input.KeyC:     ebiten.KeyC,
+	}
+	gamepad := map[input.Key]ebiten.StandardGamepadButton{
+		input.KeyGamepadX: ebiten.StandardGamepadButtonRightLeft,
+		input.KeyGamepadY: ebiten.StandardGamepadButtonRightTop,
+	}
+
+	// Every input is a key tap: it's pressed for a frame and then released,
+	// so every input takes two ticks.
+	// The zero key is an input without any keys pressed.
+	tests := []struct {
+		name   string
+		action input.Action
+		inputs []input.Key
+		want   bool
+	}{
+		{"cheat", actionCheat, []input.Key{input.KeyUp, input.KeyUp, input.KeyDown, input.KeyDown, input.KeyLeft, input.KeyRight, input.KeyB, input.KeyA}, true},
+		{"cheat extra prefix", actionCheat, []input.Key{input.KeyUp, input.KeyUp, input.KeyUp, input.KeyDown, input.KeyDown, input.KeyLeft, input.KeyRight, input.KeyB, input.KeyA}, true},
+		{"cheat unrelated key", actionCheat, []input.Key{input.KeyUp, input.KeyUp, input.KeyDown, input.KeyDown, input.KeyC, input.KeyLeft, input.KeyRight, input.KeyB, input.KeyA}, true},
+		{"cheat wrong key", actionCheat, []input.Key{input.KeyUp, input.KeyUp, input.KeyDown, input.KeyDown, input.KeyLeft, input.KeyLeft, input.KeyRight, input.KeyB, input.KeyA}, false},
+		{"cheat incomplete", actionCheat, []input.Key{input.KeyUp, input.KeyUp, input.KeyDown, input.KeyDown, input.KeyLeft, input.KeyRight, input.KeyB}, false},
+		{"combo", actionCombo, []input.Key{input.KeyGamepadX, input.KeyGamepadX, input.KeyGamepadY}, true},
+		{"combo extra prefix", actionCombo, []input.Key{input.KeyGamepadX, input.KeyGamepadX, input.KeyGamepadX, input.KeyGamepadY}, true},
+		{"combo slow second step", actionCombo, []input.Key{input.KeyGamepadX, {}, {}, {}, {}, input.KeyGamepadX, input.KeyGamepadY}, true},
+		{"combo second step timeout", actionCombo, []input.Key{input.KeyGamepadX, {}, {}, {}, {}, {}, input.KeyGamepadX, input.KeyGamepadY}, false},
+		{"combo slow last step", actionCombo, []input.Key{input.KeyGamepadX, input.KeyGamepadX, {}, input.KeyGamepadY}, true},
+		{"combo last step timeout", actionCombo, []input.Key{input.KeyGamepadX, input.KeyGamepadX, {}, {}, input.KeyGamepadY}, false},
+	}
+
+	for _, test := range tests {
+		sys, h, b := newTestHandler(input.Keymap{
+			actionCheat: {cheat},
+			actionCombo: {combo},
+		})
+		b.ConnectGamepad(0, "test gamepad")
+		completed := 0
+		for i, k := range test.inputs {
+			if k.String() != "" {
+				if key, ok := keyboard[k]; ok {
+					b.PressKey(key)
+				} else {
+					b.PressGamepadButton(0, gamepad[k])
+				}
+			}
+			sys.Update()
+			// The intermediate frames are not queried on purpose:
+			// the sequence progress is tracked anyway.
+			if i == len(test.inputs)-1 {
+				if h.ActionIsJustPressed(test.action) {
+					completed++
+				}
+			}
+			if k.String() != "" {
+				if key, ok := keyboard[k]; ok {
+					b.ReleaseKey(key)
+				} else {
+					b.ReleaseGamepadButton(0, gamepad[k])
+				}
+			}
+			sys.Update()
+			if h.ActionIsPressed(test.action) {
+				t.Fatalf("%s: the sequence is pressed after its last key is released", test.name)
+			}
+		}
+		if have := completed == 1; have != test.want {
+			t.Fatalf("%s: have %v, want %v", test.name, have, test.want)
+		}
+	}
+}
+
+func TestKeySequenceInfo(t *testing.T) {
+	sys, h, b := newTestHandler(nil)
+	h.Remap(input.Keymap{
+		actionRun: {input.KeySequence(0, input.KeyMouseLeft, input.KeyMouseRight)},
+	})
+
+	b.MoveCursor(1, 1)
+	b.PressMouseButton(ebiten.MouseButtonLeft)
+	sys.Update()
+	b.ReleaseMouseButton(ebiten.MouseButtonLeft)
+	for i := 0; i < 100; i++ {
+		sys.Update()
+	}
+	b.MoveCursor(10, 20)
+	b.PressMouseButton(ebiten.MouseButtonRight)
+	sys.Update()
+	info, ok := h.JustPressedActionInfo(actionRun)
+	if !ok {
+		t.Fatal("sequence without time limits is not completed")
+	}
+	if !info.HasPos() || info.Pos != (input.Vec{X: 10, Y: 20}) {
+		t.Fatalf("unexpected sequence pos: %v", info.Pos)
+	}
+	if info.Source() != input.MouseDevice {
+		t.Fatalf("unexpected sequence source: %s", info.Source())
+	}
+	sys.Update()
+	if h.ActionIsJustPressed(actionRun) {
+		t.Fatal("sequence is just pressed during two frames")
+	}
+}
+
+func TestKeyMultiTap(t *testing.T) {
+	const (
+		actionDash input.Action = iota + 1
+		actionOpen
+		actionZoom
+		actionTriple
+	)
+	sys, h, b := newTestHandler(input.Keymap{
+		actionDash:   {input.KeyMultiTap(input.KeyW, 2, 0.25)},
+		actionOpen:   {input.KeyMultiTap(input.KeyMouseLeft, 2, 0)},
+		actionZoom:   {input.KeyMultiTap(input.KeyTouchTap, 2, 0.5)},
+		actionTriple: {input.KeyMultiTap(input.KeyGamepadA, 3, 0.5)},
+	})
+	b.ConnectGamepad(0, "test gamepad")
+
+	if name := input.KeyMultiTap(input.KeyW, 2, 0.25).String(); name != "w*2" {
+		t.Fatalf("unexpected multi-tap name: %q", name)
+	}
+
+	const delta = 0.1
+	update := func(n int) {
+		for i := 0; i < n; i++ {
+			sys.UpdateWithDelta(delta)
+		}
+	}
+	tapKey := func(k ebiten.Key) {
+		b.PressKey(k)
+		update(1)
+		b.ReleaseKey(k)
+	}
+
+	tapKey(ebiten.KeyW)
+	if h.ActionIsJustPressed(actionDash) {
+		t.Fatal("dash is activated by a single tap")
+	}
+	update(1)
+	tapKey(ebiten.KeyW)
+	if !h.ActionIsJustPressed(actionDash) || !h.ActionIsPressed(actionDash) {
+		t.Fatal("dash is not activated by a double tap")
+	}
+	update(1)
+	if h.ActionIsPressed(actionDash) {
+		t.Fatal("dash is pressed after the double tap")
+	}
+	tapKey(ebiten.KeyW)
+	if h.ActionIsJustPressed(actionDash) {
+		t.Fatal("the third tap activated a dash")
+	}
+
+	// Too slow: 0.3s between the taps.
+	update(5)
+	tapKey(ebiten.KeyW)
+	update(2)
+	tapKey(ebiten.KeyW)
+	if h.ActionIsJustPressed(actionDash) {
+		t.Fatal("dash is activated by slow taps")
+	}
+	// But this tap is quick enough after the previous one.
+	update(1)
+	tapKey(ebiten.KeyW)
+	if !h.ActionIsJustPressed(actionDash) {
+		t.Fatal("dash is not activated after the slow taps")
+	}
+
+	b.MoveCursor(10, 10)
+	b.PressMouseButton(ebiten.MouseButtonLeft)
+	update(1)
+	b.ReleaseMouseButton(ebiten.MouseButtonLeft)
+	update(1)
+	b.MoveCursor(12, 11)
+	b.PressMouseButton(ebiten.MouseButtonLeft)
+	update(1)
+	info, ok := h.JustPressedActionInfo(actionOpen)
+	if !ok {
+		t.Fatal("double click is not registered")
+	}
+	if !info.HasPos() || info.Pos != (input.Vec{X: 12, Y: 11}) {
+		t.Fatalf("unexpected double click pos: %v", info.Pos)
+	}
+	b.ReleaseMouseButton(ebiten.MouseButtonLeft)
+
+	b.PressTouch(1, 50, 50)
+	update(1)
+	b.ReleaseTouch(1)
+	update(1)
+	b.PressTouch(2, 60, 70)
+	update(1)
+	b.ReleaseTouch(2)
+	update(1)
+	info, ok = h.JustPressedActionInfo(actionZoom)
+	if !ok {
+		t.Fatal("double tap is not registered")
+	}
+	if info.Pos != (input.Vec{X: 60, Y: 70}) || info.Source() != input.TouchDevice {
+		t.Fatalf("unexpected double tap info: pos=%v source=%s", info.Pos, info.Source())
+	}
+
+	for i := 1; i <= 3; i++ {
+		b.PressGamepadButton(0, ebiten.StandardGamepadButtonRightBottom)
+		update(1)
+		if have := h.ActionIsJustPressed(actionTriple); have != (i == 3) {
+			t.Fatalf("unexpected triple tap state after %d taps: %v", i, have)
+		}
+		b.ReleaseGamepadButton(0, ebiten.StandardGamepadButtonRightBottom)
+		update(1)
+	}
+	for i := 0; i < 3; i++ {
+		h.EmitKeyEvent(input.SimulatedKeyEvent{Key: input.KeyGamepadA})
+		update(1)
+		if i != 2 {
+			update(1)
+		}
+	}
+	if !h.ActionIsJustPressed(actionTriple) {
+		t.Fatal("triple tap is not activated by the simulated events")
+	}
+}
+
+func TestKeyHoldAndTap(t *testing.T) {
+	const (
+		actionInteract input.Action = iota + 1
+		actionUse
+		actionCharge
+	)
+	sys, h, b := newTestHandler(input.Keymap{
+		actionInteract: {input.KeyHold(input.KeyE, 1)},
+		actionUse:      {input.KeyTap(input.KeyE, 1)},
+		actionCharge:   {input.KeyHold(input.KeyMouseLeft, 0.5)},
+	})
+	if name := input.KeyHold(input.KeyE, 1).String(); name != "hold(e)" {
+		t.Fatalf("unexpected hold key name: %q", name)
+	}
+	if name := input.KeyTap(input.KeyE, 1).String(); name != "tap(e)" {
+		t.Fatalf("unexpected tap key name: %q", name)
+	}
+
+	const delta = 0.1
+	type frameState struct {
+		interact actionState
+		use      actionState
+		progress float64
+	}
+	getFrameState := func() frameState {
+		return frameState{
+			interact: getActionState(h, actionInteract),
+			use:      getActionState(h, actionUse),
+			progress: h.ActionHoldProgress(actionInteract),
+		}
+	}
+	check := func(name string, want frameState) {
+		t.Helper()
+		have := getFrameState()
+		if have.interact != want.interact || have.use != want.use || math.Abs(have.progress-want.progress) > 1e-6 {
+			t.Fatalf("%s:\nhave: %+v\nwant: %+v", name, have, want)
+		}
+	}
+
+	// A short tap.
+	b.PressKey(ebiten.KeyE)
+	sys.UpdateWithDelta(delta)
+	check("tap press", frameState{})
+	sys.UpdateWithDelta(delta)
+	check("tap hold", frameState{progress: 0.1})
+	b.ReleaseKey(ebiten.KeyE)
+	sys.UpdateWithDelta(delta)
+	check("tap release", frameState{use: actionState{justPressed: true, pressed: true}})
+	sys.UpdateWithDelta(delta)
+	check("after tap", frameState{})
+
+	// A long hold.
+	b.PressKey(ebiten.KeyE)
+	sys.UpdateWithDelta(delta)
+	for i := 1; i < 10; i++ {
+		sys.UpdateWithDelta(delta)
+		check("holding", frameState{progress: float64(i) * delta})
+	}
+	sys.UpdateWithDelta(delta)
+	check("hold threshold", frameState{interact: actionState{justPressed: true, pressed: true}, progress: 1})
+	sys.UpdateWithDelta(delta)
+	check("hold after threshold", frameState{interact: actionState{pressed: true}, progress: 1})
+	b.ReleaseKey(ebiten.KeyE)
+	sys.UpdateWithDelta(delta)
+	check("hold release", frameState{interact: actionState{justReleased: true}})
+
+	// The mouse button hold respects the time delta.
+	b.MoveCursor(3, 4)
+	b.PressMouseButton(ebiten.MouseButtonLeft)
+	sys.UpdateWithDelta(0.25)
+	sys.UpdateWithDelta(0.25)
+	if h.ActionIsPressed(actionCharge) {
+		t.Fatal("mouse hold is activated too early")
+	}
+	sys.UpdateWithDelta(0.25)
+	info, ok := h.JustPressedActionInfo(actionCharge)
+	if !ok {
+		t.Fatal("mouse hold is not activated")
+	}
+	if info.Pos != (input.Vec{X: 3, Y: 4}) {
+		t.Fatalf("unexpected mouse hold pos: %v", info.Pos)
+	}
+
+	// Simulated holds work too.
+	h.EmitKeyHold(input.SimulatedKeyEvent{Key: input.KeyE})
+	for i := 0; i < 11; i++ {
+		sys.UpdateWithDelta(delta)
+	}
+	if !h.ActionIsJustPressed(actionInteract) {
+		t.Fatal("simulated hold is not activated")
+	}
+	h.EmitKeyRelease(input.KeyE)
+	sys.UpdateWithDelta(delta)
+	if !h.ActionIsJustReleased(actionInteract) || h.ActionIsPressed(actionUse) {
+		t.Fatal("unexpected simulated hold release state")
+	}
+}
+
+func TestMultiTouch(t *testing.T) {
+	sys, h, b := newTestHandler(input.Keymap{
+		actionRun:    {input.KeyTouchTap},
+		actionCharge: {input.KeyTouchDrag},
+	})
+
+	var touches []input.TouchInfo
+	getTouches := func() []input.TouchInfo {
+		touches = h.AppendTouches(touches[:0])
+		return touches
+	}
+
+	// The left thumb starts a drag.
+	b.PressTouch(1, 100, 400)
+	sys.Update()
+	if list := getTouches(); len(list) != 1 || !list[0].IsJustPressed() || list[0].StartPos != (input.Vec{X: 100, Y: 400}) {
+		t.Fatalf("unexpected touches: %+v", list)
+	}
+	b.MoveTouch(1, 120, 380)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionCharge) {
+		t.Fatal("primary touch drag is not registered")
+	}
+
+	// The right thumb taps while the left one is dragging.
+	b.PressTouch(2, 600, 400)
+	sys.Update()
+	list := getTouches()
+	if len(list) != 2 {
+		t.Fatalf("unexpected number of touches: %d", len(list))
+	}
+	if list[0].ID != 1 || !list[0].IsDrag() || list[0].IsJustPressed() || list[0].Pos != (input.Vec{X: 120, Y: 380}) {
+		t.Fatalf("unexpected first touch: %+v", list[0])
+	}
+	if list[1].ID != 2 || !list[1].IsJustPressed() || list[1].IsDrag() {
+		t.Fatalf("unexpected second touch: %+v", list[1])
+	}
+	if list[1].Time != 0 || list[0].Time == 0 {
+		t.Fatalf("unexpected touch times: %v and %v", list[0].Time, list[1].Time)
+	}
+
+	b.ReleaseTouch(2)
+	sys.Update()
+	list = getTouches()
+	if len(list) != 2 || !list[1].IsJustReleased() || !list[1].IsTap() {
+		t.Fatalf("secondary touch tap is not registered: %+v", list)
+	}
+	if h.ActionIsPressed(actionRun) {
+		t.Fatal("secondary touch tap activated a primary touch key")
+	}
+	if !h.ActionIsPressed(actionCharge) {
+		t.Fatal("primary touch drag is interrupted by a secondary touch")
+	}
+
+	sys.Update()
+	if list := getTouches(); len(list) != 1 || list[0].ID != 1 {
+		t.Fatalf("released touch is still tracked: %+v", list)
+	}
+
+	b.ReleaseTouch(1)
+	sys.Update()
+	list = getTouches()
+	if len(list) != 1 || !list[0].IsJustReleased() || list[0].IsTap() {
+		t.Fatalf("unexpected drag release: %+v", list)
+	}
+	sys.Update()
+	if list := getTouches(); len(list) != 0 {
+		t.Fatalf("unexpected touches: %+v", list)
+	}
+
+	// The second touch becomes primary only after it's pressed
+	// while there is no other primary touch.
+	b.PressTouch(3, 10, 10)
+	sys.Update()
+	b.PressTouch(4, 20, 20)
+	sys.Update()
+	b.ReleaseTouch(4)
+	sys.Update()
+	if h.ActionIsPressed(actionRun) {
+		t.Fatal("secondary touch tap activated a primary touch key")
+	}
+	b.ReleaseTouch(3)
+	sys.Update()
+	info, ok := h.JustPressedActionInfo(actionRun)
+	if !ok || info.Pos != (input.Vec{X: 10, Y: 10}) {
+		t.Fatalf("primary touch tap is not registered: %v (ok=%v)", info.Pos, ok)
+	}
+}
+
+func TestTouchPinchRotate(t *testing.T) {
+	actionZoom := actionRun
+	actionRotate := actionCharge
+	sys, h, b := newTestHandler(input.Keymap{
+		actionZoom:   {input.KeyTouchPinch, input.KeyWithModifier(input.KeyWheelVertical, input.ModControl)},
+		actionRotate: {input.KeyTouchRotate},
+	})
+
+	b.PressTouch(1, 100, 100)
+	b.PressTouch(2, 200, 100)
+	sys.Update()
+	if h.ActionIsPressed(actionZoom) || h.ActionIsPressed(actionRotate) {
+		t.Fatal("gesture is activated without any movement")
+	}
+
+	// A small movement is below the activation threshold.
+	b.MoveTouch(2, 205, 100)
+	sys.Update()
+	if h.ActionIsPressed(actionZoom) {
+		t.Fatal("pinch is activated below the threshold")
+	}
+
+	b.MoveTouch(2, 220, 100)
+	sys.Update()
+	info, ok := h.JustPressedActionInfo(actionZoom)
+	if !ok {
+		t.Fatal("pinch is not activated")
+	}
+	if !info.HasPos() || info.Pos != (input.Vec{X: 160, Y: 100}) || info.StartPos != (input.Vec{X: 150, Y: 100}) {
+		t.Fatalf("unexpected pinch positions: %v and %v", info.Pos, info.StartPos)
+	}
+	// The movement before the activation is included.
+	if math.Abs(info.ScaleDelta-0.2) > 1e-9 {
+		t.Fatalf("unexpected scale delta: %v", info.ScaleDelta)
+	}
+	if h.ActionIsPressed(actionRotate) {
+		t.Fatal("rotate is activated without the rotation")
+	}
+
+	b.MoveTouch(2, 215, 135)
+	sys.Update()
+	if h.ActionIsJustPressed(actionZoom) || !h.ActionIsPressed(actionZoom) {
+		t.Fatal("pinch should continue without being just pressed again")
+	}
+	info, ok = h.JustPressedActionInfo(actionRotate)
+	if !ok {
+		t.Fatal("rotate is not activated")
+	}
+	if want := math.Atan2(35, 115); math.Abs(info.RotationDelta-want) > 1e-9 {
+		t.Fatalf("unexpected rotation delta: %v (want %v)", info.RotationDelta, want)
+	}
+	info, _ = h.PressedActionInfo(actionZoom)
+	if want := math.Hypot(115, 35)/120 - 1; math.Abs(info.ScaleDelta-want) > 1e-9 {
+		t.Fatalf("unexpected scale delta: %v (want %v)", info.ScaleDelta, want)
+	}
+
+	b.ReleaseTouch(2)
+	sys.Update()
+	if !h.ActionIsJustReleased(actionZoom) || !h.ActionIsJustReleased(actionRotate) {
+		t.Fatal("gesture is not released")
+	}
+	if h.ActionIsPressed(actionZoom) || h.ActionIsPressed(actionRotate) {
+		t.Fatal("gesture is still pressed after the release")
+	}
+	sys.Update()
+	if h.ActionIsJustReleased(actionZoom) {
+		t.Fatal("gesture release is reported twice")
+	}
+}
+
+func TestSwipe(t *testing.T) {
+	actionNext := actionRun
+	actionClose := actionCharge
+	sys, h, b := newTestHandler(input.Keymap{
+		actionNext:  {input.KeyTouchSwipeLeft, input.KeyMouseSwipeLeft},
+		actionClose: {input.KeyTouchSwipeUp},
+	})
+
+	b.PressTouch(1, 100, 300)
+	sys.Update()
+	b.MoveTouch(1, 100, 250)
+	sys.Update()
+	b.MoveTouch(1, 102, 200)
+	sys.Update()
+	if h.ActionIsPressed(actionClose) {
+		t.Fatal("swipe is activated before the release")
+	}
+	b.ReleaseTouch(1)
+	sys.Update()
+	info, ok := h.JustPressedActionInfo(actionClose)
+	if !ok {
+		t.Fatal("touch swipe up is not activated")
+	}
+	if info.StartPos != (input.Vec{X: 100, Y: 300}) || info.Pos != (input.Vec{X: 102, Y: 200}) {
+		t.Fatalf("unexpected swipe positions: %v and %v", info.StartPos, info.Pos)
+	}
+	if math.Abs(info.Velocity.X-60) > 1e-6 || math.Abs(info.Velocity.Y+3000) > 1e-6 {
+		t.Fatalf("unexpected swipe velocity: %v", info.Velocity)
+	}
+	if h.ActionIsPressed(actionNext) {
+		t.Fatal("swipe up activated a swipe left")
+	}
+	sys.Update()
+	if h.ActionIsPressed(actionClose) {
+		t.Fatal("swipe is pressed for more than one frame")
+	}
+
+	// A slow drag is not a swipe.
+	b.PressTouch(2, 100, 300)
+	sys.Update()
+	for y := 298; y >= 200; y -= 2 {
+		b.MoveTouch(2, 100, y)
+		sys.Update()
+	}
+	b.ReleaseTouch(2)
+	sys.Update()
+	if h.ActionIsPressed(actionClose) {
+		t.Fatal("slow drag is detected as a swipe")
+	}
+
+	// A diagonal mouse drag is not a swipe.
+	b.MoveCursor(300, 100)
+	b.PressMouseButton(ebiten.MouseButtonLeft)
+	sys.Update()
+	b.MoveCursor(200, 20)
+	sys.Update()
+	b.ReleaseMouseButton(ebiten.MouseButtonLeft)
+	sys.Update()
+	if h.ActionIsPressed(actionNext) {
+		t.Fatal("diagonal drag is detected as a swipe")
+	}
+
+	b.MoveCursor(300, 100)
+	b.PressMouseButton(ebiten.MouseButtonLeft)
+	sys.Update()
+	b.MoveCursor(200, 110)
+	sys.Update()
+	b.ReleaseMouseButton(ebiten.MouseButtonLeft)
+	sys.Update()
+	info, ok = h.JustPressedActionInfo(actionNext)
+	if !ok || !info.Source().IsMouse() {
+		t.Fatal("mouse swipe left is not activated")
+	}
+	if info.Velocity.X >= 0 {
+		t.Fatalf("unexpected swipe velocity: %v", info.Velocity)
+	}
+}
+
+func TestVirtualGamepad(t *testing.T) {
+	actionMove := actionRun
+	actionJump := actionCharge
+	actionRight := actionNoKeys
+	sys, h, b := newTestHandler(input.Keymap{
+		actionMove:  {input.KeyGamepadLStickMotion},
+		actionJump:  {input.KeyGamepadA},
+		actionRight: {input.KeyGamepadLStickRight},
+	})
+
+	stick := &input.VirtualStick{
+		Center: input.Vec{X: 100, Y: 400},
+		Radius: 50,
+	}
+	button := &input.VirtualButton{
+		Key: input.KeyGamepadA,
+		Min: input.Vec{X: 600, Y: 380},
+		Max: input.Vec{X: 680, Y: 460},
+	}
+	h.SetVirtualGamepad(&input.VirtualGamepad{
+		Buttons:   []*input.VirtualButton{button},
+		LeftStick: stick,
+	})
+
+	// A touch outside of the stick circle is ignored.
+	b.PressTouch(1, 300, 300)
+	sys.Update()
+	b.ReleaseTouch(1)
+	sys.Update()
+	if stick.IsActive() {
+		t.Fatal("stick is activated by an outside touch")
+	}
+
+	b.PressTouch(2, 100, 400)
+	sys.Update()
+	if !stick.IsActive() || h.ActionIsPressed(actionMove) {
+		t.Fatal("unexpected stick state after the touch")
+	}
+	b.MoveTouch(2, 200, 400)
+	sys.Update()
+	info, ok := h.JustPressedActionInfo(actionMove)
+	if !ok {
+		t.Fatal("stick motion is not activated")
+	}
+	if info.Pos != (input.Vec{X: 1, Y: 0}) {
+		t.Fatalf("unexpected stick value: %v", info.Pos)
+	}
+	if stick.KnobPos() != (input.Vec{X: 150, Y: 400}) {
+		t.Fatalf("unexpected knob pos: %v", stick.KnobPos())
+	}
+	if !h.ActionIsPressed(actionRight) {
+		t.Fatal("stick D-pad emulation is not activated")
+	}
+
+	b.PressTouch(3, 640, 420)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionJump) || !button.IsPressed() {
+		t.Fatal("virtual button is not pressed")
+	}
+	if !h.ActionIsPressed(actionMove) {
+		t.Fatal("stick is released by a button touch")
+	}
+	b.ReleaseTouch(3)
+	sys.Update()
+	if !h.ActionIsJustReleased(actionJump) || h.ActionIsPressed(actionJump) {
+		t.Fatal("virtual button is not released")
+	}
+
+	b.ReleaseTouch(2)
+	sys.Update()
+	if stick.IsActive() || stick.KnobPos() != stick.Center || h.ActionIsPressed(actionMove) {
+		t.Fatal("stick is not released")
+	}
+
+	// A floating stick is centered at the touch start position.
+	stick.Floating = true
+	b.PressTouch(4, 120, 380)
+	sys.Update()
+	b.MoveTouch(4, 120, 405)
+	sys.Update()
+	if stick.Origin() != (input.Vec{X: 120, Y: 380}) || stick.Value() != (input.Vec{X: 0, Y: 0.5}) {
+		t.Fatalf("unexpected floating stick state: %v and %v", stick.Origin(), stick.Value())
+	}
+}
+
+func TestVirtualGamepadTouchGestures(t *testing.T) {
+	actionTap := actionRun
+	actionDrag := actionCharge
+	actionJump := actionNoKeys
+	sys, h, b := newTestHandler(input.Keymap{
+		actionTap:  {input.KeyTouchTap},
+		actionDrag: {input.KeyTouchDrag},
+		actionJump: {input.KeyGamepadA},
+	})
+
+	stick := &input.VirtualStick{
+		Center: input.Vec{X: 100, Y: 400},
+		Radius: 50,
+	}
+	button := &input.VirtualButton{
+		Key: input.KeyGamepadA,
+		Min: input.Vec{X: 600, Y: 380},
+		Max: input.Vec{X: 680, Y: 460},
+	}
+	h.SetVirtualGamepad(&input.VirtualGamepad{
+		Buttons:   []*input.VirtualButton{button},
+		LeftStick: stick,
+	})
+
+	checkNoGestures := func() {
+		t.Helper()
+		if h.ActionIsJustPressed(actionTap) || h.ActionIsPressed(actionDrag) {
+			t.Fatal("a virtual gamepad touch produced a touch gesture")
+		}
+	}
+
+	// A virtual button tap is not a touch tap.
+	b.PressTouch(1, 640, 420)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionJump) {
+		t.Fatal("virtual button is not pressed")
+	}
+	checkNoGestures()
+	b.ReleaseTouch(1)
+	sys.Update()
+	checkNoGestures()
+
+	// A virtual stick movement is not a touch drag.
+	b.PressTouch(2, 100, 400)
+	sys.Update()
+	b.MoveTouch(2, 200, 400)
+	sys.Update()
+	checkNoGestures()
+
+	// Other touches still produce the gestures while the stick is active.
+	b.PressTouch(3, 300, 100)
+	sys.Update()
+	b.ReleaseTouch(3)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionTap) {
+		t.Fatal("touch tap is not activated")
+	}
+	b.ReleaseTouch(2)
+	sys.Update()
+	checkNoGestures()
+
+	// A touch that slides into a virtual button stops being a gesture.
+	b.PressTouch(4, 500, 420)
+	sys.Update()
+	b.MoveTouch(4, 640, 420)
+	sys.Update()
+	if !button.IsPressed() {
+		t.Fatal("virtual button is not pressed by a sliding touch")
+	}
+	checkNoGestures()
+	b.ReleaseTouch(4)
+	sys.Update()
+	checkNoGestures()
+}
+
+func TestGestureConfig(t *testing.T) {
+	sys, h, b := newTestHandler(input.Keymap{
+		actionRun:    {input.KeyTouchLongTap},
+		actionCharge: {input.KeyTouchDrag},
+		actionNoKeys: {input.KeyMultiTap(input.KeyW, 2, 0)},
+	})
+
+	config := sys.GestureConfig()
+	if config.LongTapDuration != 0.5 || config.TouchDragThreshold != 5 || config.MultiTapInterval != 0.3 {
+		t.Fatalf("unexpected default config: %+v", config)
+	}
+
+	sys.SetGestureConfig(input.GestureConfig{
+		LongTapDuration:    0.1,
+		TouchDragThreshold: 20,
+		MultiTapInterval:   1,
+	})
+	if sys.GestureConfig().MouseDragThreshold != 1 {
+		t.Fatal("unset field is not replaced with its default value")
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("negative threshold is accepted")
+			}
+		}()
+		sys.SetGestureConfig(input.GestureConfig{PinchThreshold: -1})
+	}()
+	if sys.GestureConfig().TouchDragThreshold != 20 {
+		t.Fatal("rejected config is applied")
+	}
+
+	// A 10px movement is below the new drag threshold.
+	b.PressTouch(1, 100, 100)
+	sys.Update()
+	for i := 0; i < 10; i++ {
+		b.MoveTouch(1, 110, 100)
+		sys.Update()
+		if h.ActionIsPressed(actionCharge) {
+			t.Fatal("drag is activated below the threshold")
+		}
+	}
+	b.ReleaseTouch(1)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionRun) {
+		t.Fatal("long tap is not activated with a shorter duration")
+	}
+
+	// The taps are 0.5s apart: too slow for the default interval,
+	// but fine for the configured one.
+	b.PressKey(ebiten.KeyW)
+	sys.Update()
+	b.ReleaseKey(ebiten.KeyW)
+	for i := 0; i < 30; i++ {
+		sys.Update()
+	}
+	b.PressKey(ebiten.KeyW)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionNoKeys) {
+		t.Fatal("multi-tap doesn't use the configured interval")
+	}
+}
+
+func TestMouseButtonDrags(t *testing.T) {
+	actionPan := actionRun
+	actionSelect := actionCharge
+	sys, h, b := newTestHandler(input.Keymap{
+		actionPan:    {input.KeyMouseRightDrag, input.KeyMouseMiddleDrag},
+		actionSelect: {input.KeyMouseLeftDrag},
+	})
+
+	b.MoveCursor(100, 100)
+	b.PressMouseButton(ebiten.MouseButtonRight)
+	sys.Update()
+	if h.ActionIsPressed(actionPan) {
+		t.Fatal("drag is activated without a cursor movement")
+	}
+	b.MoveCursor(130, 90)
+	sys.Update()
+	info, ok := h.JustPressedActionInfo(actionPan)
+	if !ok {
+		t.Fatal("right button drag is not activated")
+	}
+	if info.StartPos != (input.Vec{X: 100, Y: 100}) || info.Pos != (input.Vec{X: 130, Y: 90}) {
+		t.Fatalf("unexpected drag positions: %v and %v", info.StartPos, info.Pos)
+	}
+	if h.ActionIsPressed(actionSelect) {
+		t.Fatal("right button drag activated the left button drag")
+	}
+	b.MoveCursor(150, 80)
+	sys.Update()
+	if info, _ := h.PressedActionInfo(actionPan); info.Pos != (input.Vec{X: 150, Y: 80}) {
+		t.Fatalf("unexpected drag pos: %v", info.Pos)
+	}
+	b.ReleaseMouseButton(ebiten.MouseButtonRight)
+	sys.Update()
+	if !h.ActionIsJustReleased(actionPan) || h.ActionIsPressed(actionPan) {
+		t.Fatal("right button drag is not released")
+	}
+
+	b.PressMouseButton(ebiten.MouseButtonMiddle)
+	b.PressMouseButton(ebiten.MouseButtonLeft)
+	sys.Update()
+	b.MoveCursor(100, 100)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionPan) || !h.ActionIsJustPressed(actionSelect) {
+		t.Fatal("simultaneous middle and left button drags are not activated")
+	}
+	b.ReleaseMouseButton(ebiten.MouseButtonLeft)
+	sys.Update()
+	if !h.ActionIsJustReleased(actionSelect) || !h.ActionIsPressed(actionPan) {
+		t.Fatal("left button release affected the middle button drag")
+	}
+}
+
+func TestWheelHorizontal(t *testing.T) {
+	actionScroll := actionRun
+	actionZoom := actionCharge
+	sys, h, b := newTestHandler(input.Keymap{
+		actionScroll: {input.KeyWheelRight},
+		actionZoom:   {input.KeyWithModifier(input.KeyWheelHorizontal, input.ModControl)},
+	})
+
+	b.ScrollWheel(-1, 0)
+	sys.Update()
+	if h.ActionIsPressed(actionScroll) || h.ActionIsPressed(actionZoom) {
+		t.Fatal("unexpected action activation by a scroll-left")
+	}
+
+	b.ScrollWheel(0.5, 0)
+	sys.Update()
+	info, ok := h.JustPressedActionInfo(actionScroll)
+	if !ok || info.Pos != (input.Vec{X: 0.5}) {
+		t.Fatalf("scroll-right is not registered: %v", info.Pos)
+	}
+
+	b.PressKey(ebiten.KeyControl)
+	b.ScrollWheel(-2, 0)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionZoom) {
+		t.Fatal("ctrl+wheel_horizontal is not activated")
+	}
+
+	// A vertical scroll doesn't activate the horizontal keys.
+	b.ScrollWheel(0, 1)
+	sys.Update()
+	if h.ActionIsPressed(actionZoom) {
+		t.Fatal("vertical scroll activated a horizontal wheel key")
+	}
+}
+
+func TestActionValue(t *testing.T) {
+	actionThrottle := actionRun
+	actionMove := actionCharge
+	sys, h, b := newTestHandler(input.Keymap{
+		actionThrottle: {input.KeyGamepadR2, input.KeyW, input.KeyGamepadLStickUp},
+		actionMove:     {input.KeyGamepadLStickMotion, input.KeyWheelVertical},
+	})
+	b.ConnectGamepad(0, "test gamepad")
+	sys.Update()
+
+	if v := h.ActionValue(actionThrottle); v != 0 {
+		t.Fatalf("unexpected value for an inactive action: %v", v)
+	}
+
+	// A slightly pressed trigger is not pressed, but it has a value.
+	b.SetGamepadButtonValue(0, ebiten.StandardGamepadButtonFrontBottomRight, 0.25)
+	sys.Update()
+	if h.ActionIsPressed(actionThrottle) || h.ActionValue(actionThrottle) != 0.25 {
+		t.Fatalf("unexpected trigger value: %v", h.ActionValue(actionThrottle))
+	}
+	if v := h.ActionVector(actionThrottle); v != (input.Vec{X: 0.25}) {
+		t.Fatalf("unexpected trigger vector: %v", v)
+	}
+
+	// Digital keys report 1, the max value is used.
+	b.PressKey(ebiten.KeyW)
+	sys.Update()
+	if v := h.ActionValue(actionThrottle); v != 1 {
+		t.Fatalf("unexpected keyboard key value: %v", v)
+	}
+	b.ReleaseKey(ebiten.KeyW)
+	b.SetGamepadButtonValue(0, ebiten.StandardGamepadButtonFrontBottomRight, 0)
+
+	// The stick direction key reports the displacement in its direction.
+	b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickHorizontal, 0.6)
+	b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickVertical, -0.8)
+	sys.Update()
+	if v := h.ActionValue(actionThrottle); math.Abs(v-0.8) > 1e-9 {
+		t.Fatalf("unexpected stick direction value: %v", v)
+	}
+	if v := h.ActionValue(actionMove); math.Abs(v-1) > 1e-9 {
+		t.Fatalf("unexpected stick motion value: %v", v)
+	}
+	if v := h.ActionVector(actionMove); v != (input.Vec{X: 0.6, Y: -0.8}) {
+		t.Fatalf("unexpected stick motion vector: %v", v)
+	}
+
+	b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickHorizontal, 0)
+	b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickVertical, 0)
+	b.ScrollWheel(0, 2.5)
+	sys.Update()
+	if v := h.ActionValue(actionMove); v != 2.5 {
+		t.Fatalf("unexpected wheel value: %v", v)
+	}
+	if v := h.ActionVector(actionMove); v != (input.Vec{Y: 2.5}) {
+		t.Fatalf("unexpected wheel vector: %v", v)
+	}
+}
+
+func TestKeyAxisVector2(t *testing.T) {
+	actionMove := actionRun
+	actionTurn := actionCharge
+	sys, h, b := newTestHandler(input.Keymap{
+		actionMove: {input.KeyVector2(input.KeyW, input.KeyS, input.KeyA, input.KeyD), input.KeyGamepadLStickMotion},
+		actionTurn: {input.KeyAxis(input.KeyLeft, input.KeyRight)},
+	})
+	b.ConnectGamepad(0, "test gamepad")
+
+	if name := input.KeyVector2(input.KeyW, input.KeyS, input.KeyA, input.KeyD).String(); name != "vector(w,s,a,d)" {
+		t.Fatalf("unexpected vector key name: %q", name)
+	}
+
+	// The diagonal vector is normalized.
+	b.PressKey(ebiten.KeyW)
+	b.PressKey(ebiten.KeyD)
+	sys.Update()
+	info, ok := h.JustPressedActionInfo(actionMove)
+	if !ok {
+		t.Fatal("vector key is not activated")
+	}
+	want := input.Vec{X: math.Sqrt2 / 2, Y: -math.Sqrt2 / 2}
+	if v := h.ActionVector(actionMove); math.Abs(v.X-want.X) > 1e-9 || math.Abs(v.Y-want.Y) > 1e-9 || info.Pos != v {
+		t.Fatalf("unexpected diagonal vector: %v (pos %v)", v, info.Pos)
+	}
+	h.AxisRawDiagonals = true
+	sys.Update()
+	if v := h.ActionVector(actionMove); v != (input.Vec{X: 1, Y: -1}) {
+		t.Fatalf("unexpected raw diagonal vector: %v", v)
+	}
+	h.AxisRawDiagonals = false
+
+	b.ReleaseKey(ebiten.KeyW)
+	b.ReleaseKey(ebiten.KeyD)
+	sys.Update()
+	if !h.ActionIsJustReleased(actionMove) || h.ActionIsPressed(actionMove) {
+		t.Fatal("vector key is not released")
+	}
+
+	// The stick motion is combined with the digital keys in the same action.
+	b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickHorizontal, -0.3)
+	sys.Update()
+	if v := h.ActionVector(actionMove); v != (input.Vec{X: -0.3}) {
+		t.Fatalf("unexpected stick vector: %v", v)
+	}
+
+	// The opposing keys cancel each other out by default.
+	b.PressKey(ebiten.KeyLeft)
+	sys.Update()
+	if v := h.ActionValue(actionTurn); v != -1 {
+		t.Fatalf("unexpected axis value: %v", v)
+	}
+	b.PressKey(ebiten.KeyRight)
+	sys.Update()
+	if v := h.ActionValue(actionTurn); v != 0 || h.ActionIsPressed(actionTurn) {
+		t.Fatalf("opposing keys are not cancelled: %v", v)
+	}
+	h.AxisLastPressedWins = true
+	sys.Update()
+	if v := h.ActionValue(actionTurn); v != 1 {
+		t.Fatalf("last pressed key doesn't win: %v", v)
+	}
+	b.ReleaseKey(ebiten.KeyRight)
+	sys.Update()
+	if v := h.ActionValue(actionTurn); v != -1 {
+		t.Fatalf("unexpected axis value after the release: %v", v)
+	}
+}
+
+func TestGamepadTriggers(t *testing.T) {
+	actionFire := actionRun
+	actionAim := actionCharge
+	sys, h, b := newTestHandler(input.Keymap{
+		actionFire: {input.KeyGamepadRTrigger},
+		actionAim:  {input.KeyTriggerThreshold(input.KeyGamepadRTrigger, 0.9)},
+	})
+	b.ConnectGamepad(0, "test gamepad")
+	sys.Update()
+
+	if k := input.KeyTriggerThreshold(input.KeyGamepadRTrigger, 0.9); k.String() != "gamepad_rtrigger@0.9" {
+		t.Fatalf("unexpected key name: %q", k.String())
+	}
+
+	setTrigger := func(v float64) {
+		b.SetGamepadButtonValue(0, ebiten.StandardGamepadButtonFrontBottomRight, v)
+		sys.Update()
+	}
+
+	// A half-pull activates only the default threshold key.
+	setTrigger(0.6)
+	if !h.ActionIsJustPressed(actionFire) || h.ActionIsPressed(actionAim) {
+		t.Fatal("half-pull should activate only the fire action")
+	}
+	info, ok := h.JustPressedActionInfo(actionFire)
+	if !ok || info.Value != 0.6 {
+		t.Fatalf("unexpected event info value: %v", info.Value)
+	}
+
+	// A full pull activates both keys.
+	setTrigger(1)
+	if h.ActionIsJustPressed(actionFire) || !h.ActionIsJustPressed(actionAim) {
+		t.Fatal("full pull should activate the aim action")
+	}
+
+	// The hysteresis keeps the keys pressed slightly below the thresholds.
+	setTrigger(0.87)
+	if !h.ActionIsPressed(actionAim) {
+		t.Fatal("aim action should be still pressed")
+	}
+	setTrigger(0.84)
+	if !h.ActionIsJustReleased(actionAim) || h.ActionIsPressed(actionAim) {
+		t.Fatal("aim action should be just released")
+	}
+	setTrigger(0.47)
+	if !h.ActionIsPressed(actionFire) {
+		t.Fatal("fire action should be still pressed")
+	}
+	setTrigger(0.44)
+	if !h.ActionIsJustReleased(actionFire) {
+		t.Fatal("fire action should be just released")
+	}
+
+	// The released trigger still has a value.
+	if v := h.ActionValue(actionFire); v != 0.44 {
+		t.Fatalf("unexpected released trigger value: %v", v)
+	}
+
+	// The threshold can be adjusted on the fly.
+	h.TriggerThreshold = 0.3
+	setTrigger(0.35)
+	if !h.ActionIsJustPressed(actionFire) {
+		t.Fatal("fire action should be pressed with a lower threshold")
+	}
+}
+
+func TestStickConfig(t *testing.T) {
+	actionMove := actionRun
+	sys, h, b := newTestHandler(input.Keymap{
+		actionMove: {input.KeyGamepadLStickMotion},
+	})
+	b.ConnectGamepad(0, "test gamepad")
+	sys.Update()
+
+	setStick := func(x, y float64) {
+		b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickHorizontal, x)
+		b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickVertical, y)
+		sys.Update()
+	}
+	checkPos := func(want input.Vec, active bool) {
+		t.Helper()
+		info, ok := h.PressedActionInfo(actionMove)
+		if ok != active {
+			t.Fatalf("unexpected action state: have %v, want %v", ok, active)
+		}
+		if !active {
+			return
+		}
+		if math.Abs(info.Pos.X-want.X) > 1e-9 || math.Abs(info.Pos.Y-want.Y) > 1e-9 {
+			t.Fatalf("unexpected stick pos: have %v, want %v", info.Pos, want)
+		}
+	}
+
+	// The default config reports the raw position.
+	setStick(0.05, 0.05)
+	checkPos(input.Vec{X: 0.05, Y: 0.05}, true)
+
+	// The default shape uses the configured inner deadzone for the activation.
+	h.LeftStickConfig = input.StickConfig{InnerDeadzone: 0.5}
+	setStick(0.2, 0)
+	checkPos(input.Vec{}, false)
+	setStick(0.3, 0.3)
+	checkPos(input.Vec{X: 0.3, Y: 0.3}, true)
+
+	// The curve doesn't affect the activation.
+	h.LeftStickConfig = input.StickConfig{Curve: input.ExponentialCurve(3)}
+	setStick(0, 0)
+	setStick(0.3, 0)
+	checkPos(input.Vec{X: 0.027}, true)
+	if !h.ActionIsJustPressed(actionMove) {
+		t.Fatal("stick motion with a curve is not just pressed")
+	}
+	setStick(0.05, 0)
+	checkPos(input.Vec{}, false)
+
+	h.LeftStickConfig = input.StickConfig{
+		Deadzone:      input.DeadzoneRadial,
+		InnerDeadzone: 0.2,
+	}
+	sys.Update()
+	checkPos(input.Vec{}, false)
+	setStick(0.3, 0)
+	checkPos(input.Vec{X: 0.3}, true)
+
+	// Only the configured stick is affected.
+	b.SetGamepadAxis(0, ebiten.StandardGamepadAxisRightStickHorizontal, 0.1)
+	sys.Update()
+	if v := h.ActionVector(actionMove); v != (input.Vec{X: 0.3}) {
+		t.Fatalf("unexpected left stick vector: %v", v)
+	}
+
+	h.LeftStickConfig = input.StickConfig{
+		Deadzone:      input.DeadzoneAxial,
+		InnerDeadzone: 0.2,
+		OuterDeadzone: 0.9,
+	}
+	setStick(0.1, 0.95)
+	checkPos(input.Vec{Y: 1}, true)
+
+	h.LeftStickConfig = input.StickConfig{
+		Deadzone:      input.DeadzoneScaledRadial,
+		InnerDeadzone: 0.2,
+		OuterDeadzone: 0.8,
+	}
+	setStick(0, -0.5)
+	checkPos(input.Vec{Y: -0.5}, true)
+	setStick(0.9, 0)
+	checkPos(input.Vec{X: 1}, true)
+
+	h.LeftStickConfig.Curve = input.ExponentialCurve(2)
+	setStick(0, -0.5)
+	checkPos(input.Vec{Y: -0.25}, true)
+
+	h.LeftStickConfig.Curve = func(v float64) float64 { return 1 }
+	setStick(-0.3, 0.4)
+	checkPos(input.Vec{X: -0.6, Y: 0.8}, true)
+}
+
+func TestStickDPad(t *testing.T) {
+	actionUp := actionRun
+	actionRight := actionCharge
+	sys, h, b := newTestHandler(input.Keymap{
+		actionUp:    {input.KeyGamepadLStickUp},
+		actionRight: {input.KeyGamepadLStickRight},
+	})
+	b.ConnectGamepad(0, "test gamepad")
+	sys.Update()
+
+	setStickAngle := func(magnitude, degrees float64) {
+		rad := degrees * math.Pi / 180
+		b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickHorizontal, magnitude*math.Cos(rad))
+		b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickVertical, magnitude*math.Sin(rad))
+		sys.Update()
+	}
+	checkState := func(up, right bool) {
+		t.Helper()
+		if h.ActionIsPressed(actionUp) != up || h.ActionIsPressed(actionRight) != right {
+			t.Fatalf("unexpected state: up=%v right=%v", h.ActionIsPressed(actionUp), h.ActionIsPressed(actionRight))
+		}
+	}
+
+	// The default config is 8-way: a diagonal activates both directions.
+	setStickAngle(1, -45)
+	checkState(true, true)
+	if !h.ActionIsJustPressed(actionUp) || !h.ActionIsJustPressed(actionRight) {
+		t.Fatal("expected the directions to be just pressed")
+	}
+	setStickAngle(0.4, -45)
+	checkState(false, false)
+	if !h.ActionIsJustReleased(actionUp) {
+		t.Fatal("expected the up direction to be just released")
+	}
+
+	h.StickDPad.FourWay = true
+	setStickAngle(1, -50)
+	checkState(true, false)
+	setStickAngle(1, -40)
+	checkState(false, true)
+
+	// The hysteresis keeps the active direction near the border.
+	h.StickDPad.ReleaseAngle = 10 * math.Pi / 180
+	h.StickDPad.ReleaseThreshold = 0.2
+	setStickAngle(1, -50)
+	checkState(false, true)
+	setStickAngle(0.35, -50)
+	checkState(false, true)
+	setStickAngle(1, -60)
+	checkState(true, false)
+	setStickAngle(0.25, -60)
+	checkState(false, false)
+
+	// The activation threshold is configurable.
+	h.StickDPad.Threshold = 0.2
+	setStickAngle(0.25, -90)
+	checkState(true, false)
+}
+
+func TestKeyRepeat(t *testing.T) {
+	actionDown := actionRun
+	actionRight := actionCharge
+	sys, h, b := newTestHandler(input.Keymap{
+		actionDown:  {input.KeyRepeat(input.KeyDown, 0.4, 0.1)},
+		actionRight: {input.KeyRepeat(input.KeyGamepadLStickRight, 0.4, 0.1)},
+	})
+	b.ConnectGamepad(0, "test gamepad")
+	sys.UpdateWithDelta(0.05)
+
+	if k := input.KeyRepeat(input.KeyDown, 0.4, 0.1); k.String() != "repeat(down)" {
+		t.Fatalf("unexpected key name: %q", k.String())
+	}
+
+	// Collect the frames when the action was just pressed.
+	var pulses []int
+	b.PressKey(ebiten.KeyDown)
+	for frame := 0; frame < 16; frame++ {
+		sys.UpdateWithDelta(0.05)
+		if !h.ActionIsPressed(actionDown) {
+			t.Fatalf("frame %d: the action should be pressed", frame)
+		}
+		if h.ActionIsJustPressed(actionDown) {
+			pulses = append(pulses, frame)
+		}
+	}
+	// The first pulse is immediate, then after 0.4s, then every 0.1s.
+	want := []int{0, 8, 10, 12, 14}
+	if len(pulses) != len(want) {
+		t.Fatalf("unexpected pulses: have %v, want %v", pulses, want)
+	}
+	for i := range want {
+		if pulses[i] != want[i] {
+			t.Fatalf("unexpected pulses: have %v, want %v", pulses, want)
+		}
+	}
+
+	b.ReleaseKey(ebiten.KeyDown)
+	sys.UpdateWithDelta(0.05)
+	if !h.ActionIsJustReleased(actionDown) || h.ActionIsPressed(actionDown) {
+		t.Fatal("the action should be just released")
+	}
+
+	// The stick D-pad emulation keys can be repeated too.
+	b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickHorizontal, 1)
+	sys.UpdateWithDelta(0.05)
+	if !h.ActionIsJustPressed(actionRight) {
+		t.Fatal("the stick action should be just pressed")
+	}
+	sys.UpdateWithDelta(0.2)
+	if h.ActionIsJustPressed(actionRight) {
+		t.Fatal("the stick action should not be repeated before the delay")
+	}
+	sys.UpdateWithDelta(0.2)
+	if !h.ActionIsJustPressed(actionRight) {
+		t.Fatal("the stick action should be repeated after the delay")
+	}
+}
+
+func TestConsumeBuffered(t *testing.T) {
+	actionJump := actionRun
+	sys, h, b := newTestHandler(input.Keymap{
+		actionJump:   {input.KeySpace, input.KeyGamepadA},
+		actionNoKeys: {},
+	})
+	b.ConnectGamepad(0, "test gamepad")
+	sys.Update()
+
+	b.PressKey(ebiten.KeySpace)
+	sys.Update()
+	if h.ConsumeBuffered(actionJump) {
+		t.Fatal("the buffering is disabled, expected false")
+	}
+
+	h.SetBufferWindow(3)
+
+	// A press is available during the buffer window.
+	b.ReleaseKey(ebiten.KeySpace)
+	sys.Update()
+	b.PressKey(ebiten.KeySpace)
+	sys.Update()
+	for i := 0; i < 3; i++ {
+		sys.Update()
+	}
+	if !h.ConsumeBuffered(actionJump) {
+		t.Fatal("expected a buffered press")
+	}
+	if h.LastDevice() != input.KeyboardDevice {
+		t.Fatalf("unexpected last device: %v", h.LastDevice())
+	}
+	// The press can be consumed only once.
+	if h.ConsumeBuffered(actionJump) {
+		t.Fatal("expected the buffered press to be consumed")
+	}
+
+	// A press older than the window is discarded.
+	b.ReleaseKey(ebiten.KeySpace)
+	b.PressGamepadButton(0, ebiten.StandardGamepadButtonRightBottom)
+	sys.Update()
+	for i := 0; i < 4; i++ {
+		sys.Update()
+	}
+	if h.ConsumeBuffered(actionJump) {
+		t.Fatal("expected the buffered press to expire")
+	}
+
+	// Simulated events are buffered too.
+	h.EmitEvent(input.SimulatedAction{Action: actionNoKeys})
+	sys.Update()
+	sys.Update()
+	if !h.ConsumeBuffered(actionNoKeys) {
+		t.Fatal("expected a buffered simulated action")
+	}
+	h.EmitKeyEvent(input.SimulatedKeyEvent{Key: input.KeySpace})
+	sys.Update()
+	if !h.ConsumeBuffered(actionJump) {
+		t.Fatal("expected a buffered simulated key")
+	}
+}
+
+func TestContextStack(t *testing.T) {
+	actionJump := actionRun
+	actionUse := actionCharge
+	actionMenuSelect := actionNoKeys
+	sys, h, b := newTestHandler(input.Keymap{
+		actionJump: {input.KeySpace, input.KeyGamepadA},
+		actionUse:  {input.KeyHold(input.KeyE, 1)},
+	})
+	b.ConnectGamepad(0, "test gamepad")
+	sys.Update()
+
+	if _, ok := h.TopContext(); ok {
+		t.Fatal("expected an empty context stack")
+	}
+
+	// The menu consumes its keys, the other gameplay keys are still visible.
+	h.PushContext(input.Context{
+		Name:   "menu",
+		Keymap: input.Keymap{actionMenuSelect: {input.KeySpace}},
+	})
+	if name, ok := h.TopContext(); !ok || name != "menu" {
+		t.Fatalf("unexpected top context: %q", name)
+	}
+	b.PressKey(ebiten.KeySpace)
+	b.PressGamepadButton(0, ebiten.StandardGamepadButtonRightBottom)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionMenuSelect) {
+		t.Fatal("menu action is not pressed")
+	}
+	if names := h.ActionKeyNames(actionJump, input.AnyDevice); len(names) != 1 || names[0] != "gamepad_a" {
+		t.Fatalf("unexpected visible jump keys: %v", names)
+	}
+	b.ReleaseKey(ebiten.KeySpace)
+	b.ReleaseGamepadButton(0, ebiten.StandardGamepadButtonRightBottom)
+
+	// The stateful keys keep their state while the contexts are changed.
+	b.PressKey(ebiten.KeyE)
+	sys.UpdateWithDelta(0.5)
+	sys.UpdateWithDelta(0.5)
+	h.PushContext(input.Context{Name: "hud", Consume: input.ConsumeNone})
+	sys.UpdateWithDelta(0.5)
+	if !h.ActionIsJustPressed(actionUse) {
+		t.Fatal("hold key state is lost after a context push")
+	}
+
+	// A modal context hides everything below it.
+	h.PushContext(input.Context{
+		Name:    "pause",
+		Keymap:  input.Keymap{},
+		Consume: input.ConsumeAll,
+	})
+	sys.UpdateWithDelta(0.5)
+	if h.ActionIsPressed(actionUse) {
+		t.Fatal("the pause context should hide the gameplay keys")
+	}
+	if !h.RemoveContext("pause") || h.RemoveContext("pause") {
+		t.Fatal("unexpected RemoveContext result")
+	}
+	b.ReleaseKey(ebiten.KeyE)
+
+	// A consumed key hides the composite keys that use it.
+	h.PushContext(input.Context{
+		Name:   "chat",
+		Keymap: input.Keymap{actionMenuSelect: {input.KeyE}},
+	})
+	if names := h.ActionKeyNames(actionUse, input.AnyDevice); len(names) != 0 {
+		t.Fatalf("unexpected visible use keys: %v", names)
+	}
+
+	for _, want := range []string{"chat", "hud", "menu"} {
+		if name, ok := h.PopContext(); !ok || name != want {
+			t.Fatalf("unexpected popped context: have %q, want %q", name, want)
+		}
+	}
+	if _, ok := h.PopContext(); ok {
+		t.Fatal("expected an empty context stack")
+	}
+	b.PressKey(ebiten.KeySpace)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionJump) || h.ActionIsPressed(actionMenuSelect) {
+		t.Fatal("the gameplay keymap is not restored")
+	}
+}
+
+func TestContextHiddenKeyState(t *testing.T) {
+	actionUse := actionCharge
+	sys, h, b := newTestHandler(input.Keymap{
+		actionUse: {input.KeyHold(input.KeyE, 1)},
+	})
+
+	b.PressKey(ebiten.KeyE)
+	sys.UpdateWithDelta(0.5)
+	sys.UpdateWithDelta(0.5)
+
+	// The hidden hold is frozen while the pause menu is open.
+	h.PushContext(input.Context{Name: "pause", Consume: input.ConsumeAll})
+	for i := 0; i < 3; i++ {
+		sys.UpdateWithDelta(0.5)
+		if h.ActionIsPressed(actionUse) {
+			t.Fatal("the pause context should hide the hold key")
+		}
+	}
+
+	// The hold continues from where it was stopped.
+	h.PopContext()
+	sys.UpdateWithDelta(0.5)
+	if !h.ActionIsJustPressed(actionUse) {
+		t.Fatal("hold key state is lost after a context pop")
+	}
+}
+
+func TestContextConsumeModifiers(t *testing.T) {
+	actionSave := actionRun
+	actionMenuSelect := actionNoKeys
+	sys, h, b := newTestHandler(input.Keymap{
+		actionSave: {input.KeyWithModifier(input.KeyS, input.ModControl)},
+	})
+
+	// A plain key hides the modified key from the lower layers.
+	h.PushContext(input.Context{
+		Name:   "menu",
+		Keymap: input.Keymap{actionMenuSelect: {input.KeyS}},
+	})
+	if names := h.ActionKeyNames(actionSave, input.AnyDevice); len(names) != 0 {
+		t.Fatalf("unexpected visible save keys: %v", names)
+	}
+	b.PressKey(ebiten.KeyControlLeft)
+	b.PressKey(ebiten.KeyS)
+	sys.Update()
+	if h.ActionIsPressed(actionSave) {
+		t.Fatal("the menu context should hide the modified key")
+	}
+	b.ReleaseKey(ebiten.KeyControlLeft)
+	b.ReleaseKey(ebiten.KeyS)
+	h.PopContext()
+
+	// And vice versa.
+	h.Remap(input.Keymap{actionSave: {input.KeyS}})
+	h.PushContext(input.Context{
+		Name:   "editor",
+		Keymap: input.Keymap{actionMenuSelect: {input.KeyWithModifier(input.KeyS, input.ModControl)}},
+	})
+	if names := h.ActionKeyNames(actionSave, input.AnyDevice); len(names) != 0 {
+		t.Fatalf("unexpected visible save keys: %v", names)
+	}
+}
+
+func TestContextBufferedAction(t *testing.T) {
+	actionJump := actionRun
+	actionMenuSelect := actionNoKeys
+	sys, h, b := newTestHandler(input.Keymap{
+		actionJump: {input.KeySpace},
+	})
+	h.SetBufferWindow(3)
+
+	h.PushContext(input.Context{
+		Name:   "menu",
+		Keymap: input.Keymap{actionMenuSelect: {input.KeyEnter}},
+	})
+	b.PressKey(ebiten.KeySpace)
+	sys.Update()
+
+	// The jump is still bound after the menu is closed,
+	// so its buffered press is kept.
+	h.PopContext()
+	sys.Update()
+	if !h.ConsumeBuffered(actionJump) {
+		t.Fatal("buffered press is lost after a context pop")
+	}
+
+	// The actions that are not bound anymore lose their buffered presses.
+	b.ReleaseKey(ebiten.KeySpace)
+	sys.Update()
+	b.PressKey(ebiten.KeySpace)
+	sys.Update()
+	h.PushContext(input.Context{Name: "pause", Consume: input.ConsumeAll})
+	h.PopContext()
+	if h.ConsumeBuffered(actionJump) {
+		t.Fatal("buffered press of a hidden action is kept")
+	}
+}
+
+func TestRemoveHandler(t *testing.T) {
+	sys, h, b := newTestHandler(input.Keymap{
+		actionCharge: {input.KeyHold(input.KeyE, 1)},
+	})
+
+	b.PressKey(ebiten.KeyE)
+	sys.UpdateWithDelta(0.5)
+	sys.UpdateWithDelta(0.5)
+
+	// The removed handler state is not updated anymore.
+	sys.RemoveHandler(h)
+	for i := 0; i < 4; i++ {
+		sys.UpdateWithDelta(0.5)
+		if h.ActionIsPressed(actionCharge) || h.ActionIsJustPressed(actionCharge) {
+			t.Fatal("removed handler is still updated")
+		}
+	}
+}
+
+func TestDigitalOnlyBackend(t *testing.T) {
+	// A custom backend that doesn't implement the AnalogButtonBackend.
+	type digitalBackend struct{ input.Backend }
+
+	b := inputtest.NewBackend()
+	sys := &input.System{}
+	sys.Init(input.SystemConfig{
+		DevicesEnabled: input.AnyDevice,
+		Backend:        digitalBackend{Backend: b},
+	})
+	h := sys.NewHandler(0, input.Keymap{
+		actionRun: {input.KeyGamepadR2},
+	})
+	b.ConnectGamepad(0, "test gamepad")
+	sys.Update()
+
+	b.SetGamepadButtonValue(0, ebiten.StandardGamepadButtonFrontBottomRight, 0.75)
+	sys.Update()
+	if v := h.ActionValue(actionRun); v != 1 {
+		t.Fatalf("unexpected digital button value: %v", v)
+	}
+}
+
+func TestStickDPadWithoutRemap(t *testing.T) {
+	keymap := input.Keymap{}
+	sys, h, b := newTestHandler(keymap)
+	b.ConnectGamepad(0, "test gamepad")
+	sys.Update()
+
+	// The keymap is modified in place, the handler has no states for these keys.
+	keymap[actionRun] = []input.Key{input.KeyGamepadLStickUp}
+	b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickVertical, -1)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionRun) || !h.ActionIsPressed(actionRun) {
+		t.Fatal("stick direction is not pressed")
+	}
+	b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickVertical, 0)
+	sys.Update()
+	if !h.ActionIsJustReleased(actionRun) || h.ActionIsPressed(actionRun) {
+		t.Fatal("stick direction is not released")
+	}
+}
+
+func TestTriggerWithoutRemap(t *testing.T) {
+	keymap := input.Keymap{}
+	sys, h, b := newTestHandler(keymap)
+	b.ConnectGamepad(0, "test gamepad")
+	sys.Update()
+
+	// The keymap is modified in place, the trigger keys don't need a Remap.
+	keymap[actionRun] = []input.Key{input.KeyGamepadRTrigger}
+	b.SetGamepadButtonValue(0, ebiten.StandardGamepadButtonFrontBottomRight, 0.8)
+	sys.Update()
+	if v := h.ActionValue(actionRun); v != 0.8 {
+		t.Fatalf("unexpected trigger value: %v", v)
+	}
+	if !h.ActionIsJustPressed(actionRun) {
+		t.Fatal("trigger is not just pressed")
+	}
+
+	// The hysteresis keeps the trigger pressed slightly below the threshold.
+	b.SetGamepadButtonValue(0, ebiten.StandardGamepadButtonFrontBottomRight, 0.47)
+	sys.Update()
+	if !h.ActionIsPressed(actionRun) || h.ActionIsJustReleased(actionRun) {
+		t.Fatal("trigger is released above the hysteresis threshold")
+	}
+	b.SetGamepadButtonValue(0, ebiten.StandardGamepadButtonFrontBottomRight, 0.4)
+	sys.Update()
+	if h.ActionIsPressed(actionRun) || !h.ActionIsJustReleased(actionRun) {
+		t.Fatal("trigger is not released below the hysteresis threshold")
+	}
+}
@@ -0,0 +1,245 @@
+package input
+
+// Context is a named keymap layer of the handler context stack.
+//
+// The contexts make it possible to switch between the game modes
+// without replacing the whole keymap. For example, a pause menu context
+// can be pushed on top of the gameplay keymap, then popped to resume the game.
+//
+// The handler keymap (the one passed to NewHandler or Remap) is the bottom layer.
+// The contexts are stacked on top of it, the last pushed context has the highest priority.
+// A context hides some keys from the lower layers, see ContextConsume.
+//
+// An action can be bound in several layers, its keys are merged then.
+// The actions that are not bound in any of the layers are never activated.
+type Context struct {
+	// Name identifies the context inside of the handler stack.
+	Name string
+
+	// Keymap is the context keymap.
+	// It should not be modified while the context is in the stack:
+	// remove the context and push it again to apply the changes.
+	Keymap Keymap
+
+	// Consume selects the keys that are hidden from the lower layers.
+	Consume ContextConsume
+}
+
+// ContextConsume describes how a context hides the keys from the lower layers.
+type ContextConsume int
+
+const (
+	// ConsumeBoundKeys hides the keys bound in the context keymap.
+	// The composite keys hide their parts too: a context with KeyHold(KeyE)
+	// hides the KeyE, KeyTap(KeyE) and all other keys that use KeyE.
+	// The key modifiers are ignored: a context with KeyE hides
+	// the ctrl+e key from the lower layers and vice versa.
+	ConsumeBoundKeys ContextConsume = iota
+
+	// ConsumeNone makes the context transparent:
+	// the lower layers see all of the keys.
+	ConsumeNone
+
+	// ConsumeAll hides all of the lower layers, like a modal window.
+	// This is useful for the pause menu or a text chat.
+	ConsumeAll
+)
+
+// PushContext adds the context on top of the handler context stack.
+//
+// The stateful keys, like KeyHold or KeySequence, keep their state
+// while they're bound in any of the stack layers.
+// The hidden keys are not updated, their state is resumed
+// when the context that hides them is removed.
+// The input buffer (see SetBufferWindow) keeps the presses of the actions
+// that are still bound after the stack change.
+//
+// It panics if a context with the same name is already in the stack.
+func (h *Handler) PushContext(c Context) {
+	if h.HasContext(c.Name) {
+		panic("duplicated input context " + c.Name)
+	}
+	h.contexts = append(h.contexts, c)
+	h.updateContextKeymap()
+}
+
+// PopContext removes the top context from the stack and returns its name.
+// It returns false if there are no contexts in the stack.
+func (h *Handler) PopContext() (string, bool) {
+	if len(h.contexts) == 0 {
+		return "", false
+	}
+	c := h.contexts[len(h.contexts)-1]
+	h.contexts = h.contexts[:len(h.contexts)-1]
+	h.updateContextKeymap()
+	return c.Name, true
+}
+
+// RemoveContext removes the named context from any stack position.
+// It returns false if there is no such context in the stack.
+func (h *Handler) RemoveContext(name string) bool {
+	for i, c := range h.contexts {
+		if c.Name != name {
+			continue
+		}
+		h.contexts = append(h.contexts[:i], h.contexts[i+1:]...)
+		h.updateContextKeymap()
+		return true
+	}
+	return false
+}
+
+// TopContext returns the name of the top context in the stack.
+// It returns false if there are no contexts in the stack.
+func (h *Handler) TopContext() (string, bool) {
+	if len(h.contexts) == 0 {
+		return "", false
+	}
+	return h.contexts[len(h.contexts)-1].Name, true
+}
+
+// HasContext reports whether the named context is in the stack.
+func (h *Handler) HasContext(name string) bool {
+	for _, c := range h.contexts {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// updateContextKeymap applies the context stack changes.
+// Unlike Remap, it keeps the key states and the buffered actions.
+func (h *Handler) updateContextKeymap() {
+	prevStates := h.keyStates
+	hiddenStates := h.hiddenKeyStates
+	h.keymap = h.contextKeymap()
+	h.specificKeys = nil
+	h.hiddenKeyStates = nil
+	h.initKeyStates()
+
+	var stackKeys map[consumedKey]struct{}
+	for _, states := range [...]map[Key]*keyState{prevStates, hiddenStates} {
+		for k, prev := range states {
+			if st, ok := h.keyStates[k]; ok {
+				*st = *prev
+				continue
+			}
+			// Keep the hidden key state only while the key is bound
+			// somewhere in the stack, so it can become visible again.
+			if stackKeys == nil {
+				stackKeys = h.contextStackKeys()
+			}
+			if _, ok := stackKeys[consumedKeyOf(k)]; !ok {
+				continue
+			}
+			prev.justPressed = false
+			prev.justReleased = false
+			if h.hiddenKeyStates == nil {
+				h.hiddenKeyStates = make(map[Key]*keyState)
+			}
+			h.hiddenKeyStates[k] = prev
+		}
+	}
+
+	for action := range h.bufferedActions {
+		if _, ok := h.keymap[action]; !ok {
+			delete(h.bufferedActions, action)
+		}
+	}
+}
+
+// contextStackKeys returns all keys bound in the context stack layers,
+// including the composite key parts.
+func (h *Handler) contextStackKeys() map[consumedKey]struct{} {
+	keys := make(map[consumedKey]struct{})
+	addLayer := func(layer Keymap) {
+		for _, actionKeys := range layer {
+			for _, k := range actionKeys {
+				consumeKey(k, keys)
+			}
+		}
+	}
+	addLayer(h.baseKeymap)
+	for _, c := range h.contexts {
+		addLayer(c.Keymap)
+	}
+	return keys
+}
+
+// contextKeymap returns an effective keymap of the context stack.
+func (h *Handler) contextKeymap() Keymap {
+	if len(h.contexts) == 0 {
+		return h.baseKeymap
+	}
+
+	keymap := make(Keymap)
+	consumed := make(map[consumedKey]struct{})
+	addLayer := func(layer Keymap) {
+		for action, keys := range layer {
+			visible := keymap[action]
+			if visible == nil {
+				// An action without visible keys is still bound,
+				// so it can be activated by the simulated events.
+				visible = []Key{}
+			}
+			for _, k := range keys {
+				if !keyIsConsumed(k, consumed) {
+					visible = append(visible, k)
+				}
+			}
+			keymap[action] = visible
+		}
+	}
+
+	for i := len(h.contexts) - 1; i >= 0; i-- {
+		c := h.contexts[i]
+		addLayer(c.Keymap)
+		switch c.Consume {
+		case ConsumeAll:
+			return keymap
+		case ConsumeBoundKeys:
+			for _, keys := range c.Keymap {
+				for _, k := range keys {
+					consumeKey(k, consumed)
+				}
+			}
+		}
+	}
+	addLayer(h.baseKeymap)
+	return keymap
+}
+
+// consumedKey identifies a consumed key.
+// The modifiers are not a part of it, so KeyE consumes the ctrl+e too.
+type consumedKey struct {
+	kind keyKind
+	code int
+}
+
+func consumedKeyOf(k Key) consumedKey {
+	return consumedKey{kind: k.kind, code: k.code}
+}
+
+func consumeKey(k Key, consumed map[consumedKey]struct{}) {
+	consumed[consumedKeyOf(k)] = struct{}{}
+	if keyIsComposite(k.kind) {
+		for _, member := range getCompositeKey(k).keys {
+			consumeKey(member, consumed)
+		}
+	}
+}
+
+func keyIsConsumed(k Key, consumed map[consumedKey]struct{}) bool {
+	if _, ok := consumed[consumedKeyOf(k)]; ok {
+		return true
+	}
+	if keyIsComposite(k.kind) {
+		for _, member := range getCompositeKey(k).keys {
+			if keyIsConsumed(member, consumed) {
+				return true
+			}
+		}
+	}
+	return false
+}
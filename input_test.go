@@ -157,3 +157,48 @@ func TestKeymapMerge(t *testing.T) {
 		})
 	}
 }
+
+func TestHandlerRegistration(t *testing.T) {
+	var sys System
+	sys.Init(SystemConfig{DevicesEnabled: AnyDevice})
+
+	// The stick direction and trigger keys use a lazily updated state.
+	for i := 0; i < 100; i++ {
+		sys.NewHandler(0, Keymap{
+			1: {KeyGamepadLStickUp, KeyGamepadLeft},
+			2: {KeyGamepadRTrigger, KeyTriggerThreshold(KeyGamepadLTrigger, 0.3)},
+		})
+	}
+	if len(sys.handlers) != 0 {
+		t.Fatalf("the stick direction and trigger keys should not register the handler, have %d", len(sys.handlers))
+	}
+
+	stateless := sys.NewHandler(0, Keymap{1: {KeySpace}})
+	stateful := sys.NewHandler(1, Keymap{1: {KeyHold(KeyE, 1)}})
+	if len(sys.handlers) != 1 || sys.handlers[0] != stateful {
+		t.Fatalf("only the handlers with stateful keys should be registered, have %d", len(sys.handlers))
+	}
+
+	stateless.SetBufferWindow(5)
+	if len(sys.handlers) != 2 {
+		t.Fatal("a handler with an input buffer is not registered")
+	}
+	stateless.SetBufferWindow(0)
+	if len(sys.handlers) != 1 {
+		t.Fatal("a handler without an input buffer is still registered")
+	}
+
+	stateful.Remap(Keymap{1: {KeyE}})
+	if len(sys.handlers) != 0 {
+		t.Fatal("a handler without stateful keys is still registered")
+	}
+	stateful.Remap(Keymap{1: {KeyHold(KeyE, 1)}})
+	sys.RemoveHandler(stateful)
+	if len(sys.handlers) != 0 {
+		t.Fatal("a removed handler is still registered")
+	}
+	stateful.SetBufferWindow(5)
+	if len(sys.handlers) != 0 {
+		t.Fatal("a removed handler is registered again")
+	}
+}
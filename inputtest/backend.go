@@ -0,0 +1,426 @@
+// Package inputtest implements a scriptable fake input backend.
+//
+// It's intended to be used in tests that need to exercise the
+// input.Handler queries without a real ebitengine game loop:
+//
+//	b := inputtest.NewBackend()
+//	var sys input.System
+//	sys.Init(input.SystemConfig{DevicesEnabled: input.AnyDevice, Backend: b})
+//	h := sys.NewHandler(0, keymap)
+//
+//	b.PressKey(ebiten.KeySpace)
+//	sys.Update()
+//	h.ActionIsJustPressed(ActionJump) // => true
+//
+// All state changes are applied during the next System.Update() call,
+// so every Update() call is a single frame in terms of this backend.
+package inputtest
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	input "github.com/quasilyte/ebitengine-input"
+)
+
+// Backend is a fake input.Backend implementation.
+//
+// Use its methods to change the devices state between the
+// System.Update() calls.
+// Use NewBackend to create a usable object of this type.
+type Backend struct {
+	// next is modified by the Press/Release/etc methods.
+	// It becomes the current state during the Update call.
+	next deviceState
+
+	cur  deviceState
+	prev deviceState
+
+	keyDurations [ebiten.KeyMax + 1]int
+}
+
+var _ input.Backend = (*Backend)(nil)
+var _ input.AnalogButtonBackend = (*Backend)(nil)
+
+type deviceState struct {
+	keys         [ebiten.KeyMax + 1]bool
+	mouseButtons [ebiten.MouseButtonMax + 1]bool
+	cursorX      int
+	cursorY      int
+	wheelX       float64
+	wheelY       float64
+	touches      []touchState
+	gamepads     []gamepadState
+}
+
+type touchState struct {
+	id ebiten.TouchID
+	x  int
+	y  int
+}
+
+type gamepadState struct {
+	id      ebiten.GamepadID
+	name    string
+	buttons [ebiten.StandardGamepadButtonMax + 1]bool
+	values  [ebiten.StandardGamepadButtonMax + 1]float64
+	axes    [ebiten.StandardGamepadAxisMax + 1]float64
+}
+
+// NewBackend creates a fake backend with no keys pressed
+// and no gamepads connected.
+func NewBackend() *Backend {
+	return &Backend{}
+}
+
+// PressKey makes the keyboard key pressed starting from the next frame.
+// The key remains pressed until ReleaseKey is called.
+func (b *Backend) PressKey(k ebiten.Key) {
+	b.next.keys[k] = true
+}
+
+// ReleaseKey makes the keyboard key released starting from the next frame.
+func (b *Backend) ReleaseKey(k ebiten.Key) {
+	b.next.keys[k] = false
+}
+
+// PressMouseButton makes the mouse button pressed starting from the next frame.
+// The button remains pressed until ReleaseMouseButton is called.
+func (b *Backend) PressMouseButton(button ebiten.MouseButton) {
+	b.next.mouseButtons[button] = true
+}
+
+// ReleaseMouseButton makes the mouse button released starting from the next frame.
+func (b *Backend) ReleaseMouseButton(button ebiten.MouseButton) {
+	b.next.mouseButtons[button] = false
+}
+
+// MoveCursor sets the mouse cursor position for the next frame.
+func (b *Backend) MoveCursor(x, y int) {
+	b.next.cursorX = x
+	b.next.cursorY = y
+}
+
+// ScrollWheel sets the wheel offsets for the next frame.
+// Unlike the other state, the wheel offsets are reset after every frame.
+func (b *Backend) ScrollWheel(xoff, yoff float64) {
+	b.next.wheelX = xoff
+	b.next.wheelY = yoff
+}
+
+// PressTouch starts a new touch at the given position.
+// If touch with this ID is already active, it's moved to the given position.
+func (b *Backend) PressTouch(id ebiten.TouchID, x, y int) {
+	if t := b.next.findTouch(id); t != nil {
+		t.x = x
+		t.y = y
+		return
+	}
+	b.next.touches = append(b.next.touches, touchState{id: id, x: x, y: y})
+}
+
+// MoveTouch changes the position of the active touch.
+// It does nothing if there is no such touch.
+func (b *Backend) MoveTouch(id ebiten.TouchID, x, y int) {
+	if t := b.next.findTouch(id); t != nil {
+		t.x = x
+		t.y = y
+	}
+}
+
+// ReleaseTouch finishes the active touch.
+func (b *Backend) ReleaseTouch(id ebiten.TouchID) {
+	for i, t := range b.next.touches {
+		if t.id == id {
+			b.next.touches = append(b.next.touches[:i], b.next.touches[i+1:]...)
+			return
+		}
+	}
+}
+
+// ConnectGamepad adds a standard layout gamepad with a given ID.
+// Note that the handler uses its player ID as a gamepad ID.
+func (b *Backend) ConnectGamepad(id ebiten.GamepadID, name string) {
+	if b.next.findGamepad(id) != nil {
+		return
+	}
+	b.next.gamepads = append(b.next.gamepads, gamepadState{id: id, name: name})
+}
+
+// DisconnectGamepad removes the gamepad with a given ID.
+func (b *Backend) DisconnectGamepad(id ebiten.GamepadID) {
+	for i, g := range b.next.gamepads {
+		if g.id == id {
+			b.next.gamepads = append(b.next.gamepads[:i], b.next.gamepads[i+1:]...)
+			return
+		}
+	}
+}
+
+// PressGamepadButton makes the gamepad button pressed starting from the next frame.
+// It panics if there is no connected gamepad with such ID.
+func (b *Backend) PressGamepadButton(id ebiten.GamepadID, button ebiten.StandardGamepadButton) {
+	b.SetGamepadButtonValue(id, button, 1)
+}
+
+// ReleaseGamepadButton makes the gamepad button released starting from the next frame.
+// It panics if there is no connected gamepad with such ID.
+func (b *Backend) ReleaseGamepadButton(id ebiten.GamepadID, button ebiten.StandardGamepadButton) {
+	b.SetGamepadButtonValue(id, button, 0)
+}
+
+// SetGamepadButtonValue changes the analog gamepad button value starting from the next frame.
+// This is useful for the pressure-sensitive buttons, like triggers.
+// The value is expected to be in [0, 1] range, the button is pressed if its value is above 0.5.
+// It panics if there is no connected gamepad with such ID.
+func (b *Backend) SetGamepadButtonValue(id ebiten.GamepadID, button ebiten.StandardGamepadButton, value float64) {
+	g := b.mustFindGamepad(id)
+	g.values[button] = value
+	g.buttons[button] = value > 0.5
+}
+
+// SetGamepadAxis changes the gamepad axis value starting from the next frame.
+// The value is expected to be in [-1, 1] range.
+// It panics if there is no connected gamepad with such ID.
+func (b *Backend) SetGamepadAxis(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis, value float64) {
+	b.mustFindGamepad(id).axes[axis] = value
+}
+
+// Update implements input.Backend interface.
+//
+// It's called by the input.System, you don't need to call it directly.
+func (b *Backend) Update() {
+	b.prev.copyFrom(&b.cur)
+	b.cur.copyFrom(&b.next)
+	b.next.wheelX = 0
+	b.next.wheelY = 0
+
+	// Emulate the virtual keys that ebitengine provides.
+	b.cur.keys[ebiten.KeyAlt] = b.cur.keys[ebiten.KeyAlt] || b.cur.keys[ebiten.KeyAltLeft] || b.cur.keys[ebiten.KeyAltRight]
+	b.cur.keys[ebiten.KeyControl] = b.cur.keys[ebiten.KeyControl] || b.cur.keys[ebiten.KeyControlLeft] || b.cur.keys[ebiten.KeyControlRight]
+	b.cur.keys[ebiten.KeyShift] = b.cur.keys[ebiten.KeyShift] || b.cur.keys[ebiten.KeyShiftLeft] || b.cur.keys[ebiten.KeyShiftRight]
+	b.cur.keys[ebiten.KeyMeta] = b.cur.keys[ebiten.KeyMeta] || b.cur.keys[ebiten.KeyMetaLeft] || b.cur.keys[ebiten.KeyMetaRight]
+
+	for k, pressed := range b.cur.keys {
+		if pressed {
+			b.keyDurations[k]++
+		} else {
+			b.keyDurations[k] = 0
+		}
+	}
+}
+
+func (b *Backend) IsKeyPressed(k ebiten.Key) bool {
+	return b.cur.keys[k]
+}
+
+func (b *Backend) IsKeyJustPressed(k ebiten.Key) bool {
+	return b.cur.keys[k] && !b.prev.keys[k]
+}
+
+func (b *Backend) IsKeyJustReleased(k ebiten.Key) bool {
+	return !b.cur.keys[k] && b.prev.keys[k]
+}
+
+func (b *Backend) KeyPressDuration(k ebiten.Key) int {
+	return b.keyDurations[k]
+}
+
+func (b *Backend) AppendPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if b.IsKeyPressed(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (b *Backend) AppendJustPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if b.IsKeyJustPressed(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (b *Backend) AppendJustReleasedKeys(keys []ebiten.Key) []ebiten.Key {
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if b.IsKeyJustReleased(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (b *Backend) IsMouseButtonPressed(button ebiten.MouseButton) bool {
+	return b.cur.mouseButtons[button]
+}
+
+func (b *Backend) IsMouseButtonJustPressed(button ebiten.MouseButton) bool {
+	return b.cur.mouseButtons[button] && !b.prev.mouseButtons[button]
+}
+
+func (b *Backend) IsMouseButtonJustReleased(button ebiten.MouseButton) bool {
+	return !b.cur.mouseButtons[button] && b.prev.mouseButtons[button]
+}
+
+func (b *Backend) CursorPosition() (x, y int) {
+	return b.cur.cursorX, b.cur.cursorY
+}
+
+func (b *Backend) Wheel() (xoff, yoff float64) {
+	return b.cur.wheelX, b.cur.wheelY
+}
+
+func (b *Backend) AppendTouchIDs(touches []ebiten.TouchID) []ebiten.TouchID {
+	for _, t := range b.cur.touches {
+		touches = append(touches, t.id)
+	}
+	return touches
+}
+
+func (b *Backend) AppendJustPressedTouchIDs(touches []ebiten.TouchID) []ebiten.TouchID {
+	for _, t := range b.cur.touches {
+		if b.prev.findTouch(t.id) == nil {
+			touches = append(touches, t.id)
+		}
+	}
+	return touches
+}
+
+func (b *Backend) IsTouchJustReleased(id ebiten.TouchID) bool {
+	return b.cur.findTouch(id) == nil && b.prev.findTouch(id) != nil
+}
+
+func (b *Backend) TouchPosition(id ebiten.TouchID) (x, y int) {
+	if t := b.cur.findTouch(id); t != nil {
+		return t.x, t.y
+	}
+	return 0, 0
+}
+
+func (b *Backend) AppendGamepadIDs(gamepadIDs []ebiten.GamepadID) []ebiten.GamepadID {
+	for _, g := range b.cur.gamepads {
+		gamepadIDs = append(gamepadIDs, g.id)
+	}
+	return gamepadIDs
+}
+
+func (b *Backend) GamepadName(id ebiten.GamepadID) string {
+	if g := b.cur.findGamepad(id); g != nil {
+		return g.name
+	}
+	return ""
+}
+
+func (b *Backend) GamepadAxisCount(id ebiten.GamepadID) int {
+	if b.cur.findGamepad(id) != nil {
+		return int(ebiten.StandardGamepadAxisMax) + 1
+	}
+	return 0
+}
+
+func (b *Backend) GamepadAxisValue(id ebiten.GamepadID, axis int) float64 {
+	return b.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxis(axis))
+}
+
+func (b *Backend) IsGamepadButtonPressed(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
+	return b.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButton(button))
+}
+
+func (b *Backend) IsGamepadButtonJustPressed(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
+	return b.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButton(button))
+}
+
+func (b *Backend) IsGamepadButtonJustReleased(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
+	return b.IsStandardGamepadButtonJustReleased(id, ebiten.StandardGamepadButton(button))
+}
+
+func (b *Backend) AppendJustPressedGamepadButtons(id ebiten.GamepadID, buttons []ebiten.GamepadButton) []ebiten.GamepadButton {
+	for button := ebiten.StandardGamepadButton(0); button <= ebiten.StandardGamepadButtonMax; button++ {
+		if b.IsStandardGamepadButtonJustPressed(id, button) {
+			buttons = append(buttons, ebiten.GamepadButton(button))
+		}
+	}
+	return buttons
+}
+
+func (b *Backend) AppendJustReleasedGamepadButtons(id ebiten.GamepadID, buttons []ebiten.GamepadButton) []ebiten.GamepadButton {
+	for button := ebiten.StandardGamepadButton(0); button <= ebiten.StandardGamepadButtonMax; button++ {
+		if b.IsStandardGamepadButtonJustReleased(id, button) {
+			buttons = append(buttons, ebiten.GamepadButton(button))
+		}
+	}
+	return buttons
+}
+
+func (b *Backend) IsStandardGamepadLayoutAvailable(id ebiten.GamepadID) bool {
+	return b.cur.findGamepad(id) != nil
+}
+
+func (b *Backend) StandardGamepadAxisValue(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) float64 {
+	if g := b.cur.findGamepad(id); g != nil && axis >= 0 && axis <= ebiten.StandardGamepadAxisMax {
+		return g.axes[axis]
+	}
+	return 0
+}
+
+func (b *Backend) IsStandardGamepadButtonPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	return b.cur.gamepadButtonIsPressed(id, button)
+}
+
+func (b *Backend) StandardGamepadButtonValue(id ebiten.GamepadID, button ebiten.StandardGamepadButton) float64 {
+	if g := b.cur.findGamepad(id); g != nil && button >= 0 && button <= ebiten.StandardGamepadButtonMax {
+		return g.values[button]
+	}
+	return 0
+}
+
+func (b *Backend) IsStandardGamepadButtonJustPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	return b.cur.gamepadButtonIsPressed(id, button) && !b.prev.gamepadButtonIsPressed(id, button)
+}
+
+func (b *Backend) IsStandardGamepadButtonJustReleased(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	return !b.cur.gamepadButtonIsPressed(id, button) && b.prev.gamepadButtonIsPressed(id, button)
+}
+
+func (b *Backend) mustFindGamepad(id ebiten.GamepadID) *gamepadState {
+	g := b.next.findGamepad(id)
+	if g == nil {
+		panic("gamepad is not connected")
+	}
+	return g
+}
+
+func (s *deviceState) copyFrom(other *deviceState) {
+	touches := append(s.touches[:0], other.touches...)
+	gamepads := append(s.gamepads[:0], other.gamepads...)
+	*s = *other
+	s.touches = touches
+	s.gamepads = gamepads
+}
+
+func (s *deviceState) findTouch(id ebiten.TouchID) *touchState {
+	for i := range s.touches {
+		if s.touches[i].id == id {
+			return &s.touches[i]
+		}
+	}
+	return nil
+}
+
+func (s *deviceState) findGamepad(id ebiten.GamepadID) *gamepadState {
+	for i := range s.gamepads {
+		if s.gamepads[i].id == id {
+			return &s.gamepads[i]
+		}
+	}
+	return nil
+}
+
+func (s *deviceState) gamepadButtonIsPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	g := s.findGamepad(id)
+	if g == nil || button < 0 || button > ebiten.StandardGamepadButtonMax {
+		return false
+	}
+	return g.buttons[button]
+}
@@ -0,0 +1,274 @@
+package inputtest_test
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	input "github.com/quasilyte/ebitengine-input"
+	"github.com/quasilyte/ebitengine-input/inputtest"
+)
+
+const (
+	actionUnknown input.Action = iota
+	actionJump
+	actionFire
+	actionDrag
+	actionTap
+	actionMenuUp
+	actionMove
+)
+
+func newTestSystem() (*input.System, *input.Handler, *inputtest.Backend) {
+	b := inputtest.NewBackend()
+	sys := &input.System{}
+	sys.Init(input.SystemConfig{
+		DevicesEnabled: input.AnyDevice,
+		Backend:        b,
+	})
+	h := sys.NewHandler(0, input.Keymap{
+		actionJump:   {input.KeySpace, input.KeyGamepadA},
+		actionFire:   {input.KeyMouseLeft},
+		actionDrag:   {input.KeyMouseLeftDrag, input.KeyTouchDrag},
+		actionTap:    {input.KeyTouchTap},
+		actionMenuUp: {input.KeyGamepadLStickUp},
+		actionMove:   {input.KeyGamepadLStickMotion},
+	})
+	return sys, h, b
+}
+
+func TestKeyboardPressRelease(t *testing.T) {
+	sys, h, b := newTestSystem()
+
+	sys.Update()
+	if h.ActionIsPressed(actionJump) {
+		t.Fatal("jump is pressed before any input")
+	}
+
+	b.PressKey(ebiten.KeySpace)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionJump) {
+		t.Fatal("jump is not just pressed")
+	}
+	if h.LastDevice() != input.KeyboardDevice {
+		t.Fatalf("unexpected last device: %s", h.LastDevice())
+	}
+
+	sys.Update()
+	if h.ActionIsJustPressed(actionJump) {
+		t.Fatal("jump is just pressed on the second frame")
+	}
+	info, ok := h.PressedActionInfo(actionJump)
+	if !ok {
+		t.Fatal("jump is not pressed on the second frame")
+	}
+	if info.Duration != 2 {
+		t.Fatalf("unexpected press duration: have %d, want 2", info.Duration)
+	}
+
+	b.ReleaseKey(ebiten.KeySpace)
+	sys.Update()
+	if h.ActionIsPressed(actionJump) {
+		t.Fatal("jump is pressed after release")
+	}
+	if !h.ActionIsJustReleased(actionJump) {
+		t.Fatal("jump is not just released")
+	}
+
+	sys.Update()
+	if h.ActionIsJustReleased(actionJump) {
+		t.Fatal("jump is just released on the second frame")
+	}
+}
+
+func TestMouseJustReleasedInfo(t *testing.T) {
+	sys, h, b := newTestSystem()
+
+	b.MoveCursor(10, 20)
+	b.PressMouseButton(ebiten.MouseButtonLeft)
+	sys.Update()
+	info, ok := h.JustPressedActionInfo(actionFire)
+	if !ok {
+		t.Fatal("fire is not just pressed")
+	}
+	if info.Pos != (input.Vec{X: 10, Y: 20}) {
+		t.Fatalf("unexpected press pos: %v", info.Pos)
+	}
+
+	b.MoveCursor(30, 40)
+	b.ReleaseMouseButton(ebiten.MouseButtonLeft)
+	sys.Update()
+	info, ok = h.JustReleasedActionInfo(actionFire)
+	if !ok {
+		t.Fatal("fire is not just released")
+	}
+	if info.Pos != (input.Vec{X: 30, Y: 40}) {
+		t.Fatalf("unexpected release pos: %v", info.Pos)
+	}
+}
+
+func TestMouseDrag(t *testing.T) {
+	sys, h, b := newTestSystem()
+
+	b.MoveCursor(100, 100)
+	b.PressMouseButton(ebiten.MouseButtonLeft)
+	sys.Update()
+	if h.ActionIsPressed(actionDrag) {
+		t.Fatal("drag is active without a cursor movement")
+	}
+
+	b.MoveCursor(120, 100)
+	sys.Update()
+	info, ok := h.JustPressedActionInfo(actionDrag)
+	if !ok {
+		t.Fatal("drag is not just pressed")
+	}
+	if info.StartPos != (input.Vec{X: 100, Y: 100}) {
+		t.Fatalf("unexpected drag start pos: %v", info.StartPos)
+	}
+	if info.Pos != (input.Vec{X: 120, Y: 100}) {
+		t.Fatalf("unexpected drag pos: %v", info.Pos)
+	}
+
+	b.MoveCursor(150, 110)
+	sys.Update()
+	if h.ActionIsJustPressed(actionDrag) {
+		t.Fatal("drag is just pressed while dragging")
+	}
+	info, ok = h.PressedActionInfo(actionDrag)
+	if !ok {
+		t.Fatal("drag is not pressed while dragging")
+	}
+	if info.Pos != (input.Vec{X: 150, Y: 110}) {
+		t.Fatalf("unexpected drag pos: %v", info.Pos)
+	}
+
+	b.ReleaseMouseButton(ebiten.MouseButtonLeft)
+	sys.Update()
+	if !h.ActionIsJustReleased(actionDrag) {
+		t.Fatal("drag is not just released")
+	}
+}
+
+func TestTouchTapAndDrag(t *testing.T) {
+	sys, h, b := newTestSystem()
+
+	b.PressTouch(1, 50, 50)
+	sys.Update()
+	b.ReleaseTouch(1)
+	sys.Update()
+	info, ok := h.JustPressedActionInfo(actionTap)
+	if !ok {
+		t.Fatal("tap is not registered")
+	}
+	if info.Pos != (input.Vec{X: 50, Y: 50}) {
+		t.Fatalf("unexpected tap pos: %v", info.Pos)
+	}
+
+	b.PressTouch(2, 50, 50)
+	sys.Update()
+	b.MoveTouch(2, 80, 50)
+	sys.Update()
+	info, ok = h.JustPressedActionInfo(actionDrag)
+	if !ok {
+		t.Fatal("touch drag is not registered")
+	}
+	if info.StartPos != (input.Vec{X: 50, Y: 50}) || info.Pos != (input.Vec{X: 80, Y: 50}) {
+		t.Fatalf("unexpected drag info: %v => %v", info.StartPos, info.Pos)
+	}
+	b.ReleaseTouch(2)
+	sys.Update()
+	if h.ActionIsPressed(actionTap) {
+		t.Fatal("drag gesture release triggered a tap")
+	}
+}
+
+func TestGamepadButtons(t *testing.T) {
+	sys, h, b := newTestSystem()
+
+	b.ConnectGamepad(0, "test gamepad")
+	sys.Update()
+	if !h.GamepadConnected() {
+		t.Fatal("gamepad is not connected")
+	}
+
+	b.PressGamepadButton(0, ebiten.StandardGamepadButtonRightBottom)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionJump) {
+		t.Fatal("jump is not just pressed")
+	}
+	if h.LastDevice() != input.GamepadDevice {
+		t.Fatalf("unexpected last device: %s", h.LastDevice())
+	}
+
+	b.ReleaseGamepadButton(0, ebiten.StandardGamepadButtonRightBottom)
+	sys.Update()
+	if !h.ActionIsJustReleased(actionJump) {
+		t.Fatal("jump is not just released")
+	}
+
+	// The handler with ID=1 should not see the gamepad events of ID=0.
+	h2 := sys.NewHandler(1, input.Keymap{actionJump: {input.KeyGamepadA}})
+	b.PressGamepadButton(0, ebiten.StandardGamepadButtonRightBottom)
+	sys.Update()
+	if h2.ActionIsPressed(actionJump) {
+		t.Fatal("another player's gamepad triggered an action")
+	}
+}
+
+func TestGamepadStick(t *testing.T) {
+	sys, h, b := newTestSystem()
+
+	b.ConnectGamepad(0, "test gamepad")
+	sys.Update()
+
+	b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickVertical, -0.3)
+	sys.Update()
+	if h.ActionIsPressed(actionMenuUp) {
+		t.Fatal("stick up is activated below the threshold")
+	}
+	info, ok := h.JustPressedActionInfo(actionMove)
+	if !ok {
+		t.Fatal("stick motion is not just pressed")
+	}
+	if info.Pos != (input.Vec{X: 0, Y: -0.3}) {
+		t.Fatalf("unexpected stick motion pos: %v", info.Pos)
+	}
+
+	b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickVertical, -0.9)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionMenuUp) {
+		t.Fatal("stick up is not just pressed")
+	}
+	sys.Update()
+	if h.ActionIsJustPressed(actionMenuUp) || !h.ActionIsPressed(actionMenuUp) {
+		t.Fatal("stick up should be pressed, but not just pressed")
+	}
+
+	b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickVertical, 0)
+	sys.Update()
+	if h.ActionIsPressed(actionMenuUp) || h.ActionIsPressed(actionMove) {
+		t.Fatal("stick is active in the home position")
+	}
+}
+
+func TestWheel(t *testing.T) {
+	b := inputtest.NewBackend()
+	var sys input.System
+	sys.Init(input.SystemConfig{
+		DevicesEnabled: input.AnyDevice,
+		Backend:        b,
+	})
+	h := sys.NewHandler(0, input.Keymap{
+		actionMenuUp: {input.KeyWheelUp},
+	})
+
+	b.ScrollWheel(0, -1)
+	sys.Update()
+	if !h.ActionIsJustPressed(actionMenuUp) {
+		t.Fatal("wheel up is not registered")
+	}
+	sys.Update()
+	if h.ActionIsJustPressed(actionMenuUp) {
+		t.Fatal("wheel offsets are not reset after a frame")
+	}
+}
@@ -0,0 +1,25 @@
+package input
+
+// bufferedAction is a just pressed action stored in the handler input buffer.
+type bufferedAction struct {
+	// tick is a handler tick when the action was pressed.
+	tick int
+
+	// key is the key that activated the action.
+	key Key
+}
+
+// updateActionBuffer stores the keymap actions that are just pressed during this frame.
+// It's called after the key states update, so the stateful keys are taken into account.
+func (h *Handler) updateActionBuffer() {
+	for action := range h.keymap {
+		k, ok := h.justPressedActionKey(action)
+		if !ok {
+			continue
+		}
+		if h.bufferedActions == nil {
+			h.bufferedActions = make(map[Action]bufferedAction)
+		}
+		h.bufferedActions[action] = bufferedAction{tick: h.tick, key: k}
+	}
+}
@@ -0,0 +1,83 @@
+package input
+
+import (
+	"strconv"
+	"sync"
+)
+
+// compositeKey holds the data of a key that is built from other keys.
+//
+// Key is a comparable value type, so the composite key data can't
+// be stored inside of it. Instead, it lives in a global registry and
+// the Key.code holds its index. The keys are interned by their name,
+// so constructing the same composite key twice results in equal Key values.
+//
+// The registered data is never modified after the registration,
+// but the registry itself can grow concurrently, so it's protected by a mutex.
+type compositeKey struct {
+	keys []Key
+
+	// windows holds the KeySequence steps time windows.
+	windows []int
+
+	// taps and interval are KeyMultiTap parameters.
+	// A zero interval means the System GestureConfig.MultiTapInterval.
+	// For KeyRepeat, interval is a time between the repeats.
+	taps     int
+	interval float64
+
+	// duration is a KeyHold and KeyTap time threshold.
+	// For KeyRepeat, it's the initial repeat delay.
+	duration float64
+
+	// threshold is a KeyTriggerThreshold activation value.
+	threshold float64
+}
+
+type compositeKeyID struct {
+	kind keyKind
+	name string
+}
+
+var compositeKeys struct {
+	mu     sync.RWMutex
+	byName map[compositeKeyID]int
+	list   []*compositeKey
+}
+
+// registerCompositeKey returns an interned composite key.
+// The key is identified by its kind and the id string that should
+// include all of its parameters; if the id is empty, the name is used.
+func registerCompositeKey(kind keyKind, name, id string, data compositeKey) Key {
+	if id == "" {
+		id = name
+	}
+	keyID := compositeKeyID{kind: kind, name: id}
+
+	compositeKeys.mu.Lock()
+	defer compositeKeys.mu.Unlock()
+
+	index, ok := compositeKeys.byName[keyID]
+	if !ok {
+		if compositeKeys.byName == nil {
+			compositeKeys.byName = make(map[compositeKeyID]int)
+		}
+		index = len(compositeKeys.list)
+		compositeKeys.list = append(compositeKeys.list, &data)
+		compositeKeys.byName[keyID] = index
+	}
+	return Key{code: index, kind: kind, name: name}
+}
+
+// compositeKeyPartID returns a string that identifies k inside of the composite key id.
+// The names are not unique for the composite keys, so the key fields are used instead.
+func compositeKeyPartID(k Key) string {
+	return strconv.Itoa(int(k.kind)) + ":" + strconv.Itoa(k.code) + ":" + strconv.Itoa(int(k.mod))
+}
+
+func getCompositeKey(k Key) *compositeKey {
+	compositeKeys.mu.RLock()
+	data := compositeKeys.list[k.code]
+	compositeKeys.mu.RUnlock()
+	return data
+}
@@ -0,0 +1,210 @@
+package input
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// frameBackend implements the Backend queries on top of the
+// recorded frames sequence.
+//
+// Both Recorder and Player use it to answer the queries,
+// this way a live recorded session and its replay are
+// guaranteed to produce identical results.
+type frameBackend struct {
+	cur  recordedFrame
+	prev recordedFrame
+
+	keyDurations [ebiten.KeyMax + 1]int
+}
+
+func (b *frameBackend) pushFrame(f *recordedFrame) {
+	b.prev.copyFrom(&b.cur)
+	b.cur.copyFrom(f)
+	for k, pressed := range b.cur.keys {
+		if pressed {
+			b.keyDurations[k]++
+		} else {
+			b.keyDurations[k] = 0
+		}
+	}
+}
+
+func (b *frameBackend) IsKeyPressed(k ebiten.Key) bool {
+	return b.cur.keys[k]
+}
+
+func (b *frameBackend) IsKeyJustPressed(k ebiten.Key) bool {
+	return b.cur.keys[k] && !b.prev.keys[k]
+}
+
+func (b *frameBackend) IsKeyJustReleased(k ebiten.Key) bool {
+	return !b.cur.keys[k] && b.prev.keys[k]
+}
+
+func (b *frameBackend) KeyPressDuration(k ebiten.Key) int {
+	return b.keyDurations[k]
+}
+
+func (b *frameBackend) AppendPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if b.IsKeyPressed(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (b *frameBackend) AppendJustPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if b.IsKeyJustPressed(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (b *frameBackend) AppendJustReleasedKeys(keys []ebiten.Key) []ebiten.Key {
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if b.IsKeyJustReleased(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (b *frameBackend) IsMouseButtonPressed(button ebiten.MouseButton) bool {
+	return b.cur.mouseButtons[button]
+}
+
+func (b *frameBackend) IsMouseButtonJustPressed(button ebiten.MouseButton) bool {
+	return b.cur.mouseButtons[button] && !b.prev.mouseButtons[button]
+}
+
+func (b *frameBackend) IsMouseButtonJustReleased(button ebiten.MouseButton) bool {
+	return !b.cur.mouseButtons[button] && b.prev.mouseButtons[button]
+}
+
+func (b *frameBackend) CursorPosition() (x, y int) {
+	return b.cur.cursorX, b.cur.cursorY
+}
+
+func (b *frameBackend) Wheel() (xoff, yoff float64) {
+	return b.cur.wheelX, b.cur.wheelY
+}
+
+func (b *frameBackend) AppendTouchIDs(touches []ebiten.TouchID) []ebiten.TouchID {
+	for _, t := range b.cur.touches {
+		touches = append(touches, t.id)
+	}
+	return touches
+}
+
+func (b *frameBackend) AppendJustPressedTouchIDs(touches []ebiten.TouchID) []ebiten.TouchID {
+	for _, t := range b.cur.touches {
+		if b.prev.findTouch(t.id) == nil {
+			touches = append(touches, t.id)
+		}
+	}
+	return touches
+}
+
+func (b *frameBackend) IsTouchJustReleased(id ebiten.TouchID) bool {
+	return b.cur.findTouch(id) == nil && b.prev.findTouch(id) != nil
+}
+
+func (b *frameBackend) TouchPosition(id ebiten.TouchID) (x, y int) {
+	if t := b.cur.findTouch(id); t != nil {
+		return t.x, t.y
+	}
+	return 0, 0
+}
+
+func (b *frameBackend) AppendGamepadIDs(gamepadIDs []ebiten.GamepadID) []ebiten.GamepadID {
+	for _, g := range b.cur.gamepads {
+		gamepadIDs = append(gamepadIDs, g.id)
+	}
+	return gamepadIDs
+}
+
+func (b *frameBackend) GamepadName(id ebiten.GamepadID) string {
+	if g := b.cur.findGamepad(id); g != nil {
+		return g.name
+	}
+	return ""
+}
+
+func (b *frameBackend) GamepadAxisCount(id ebiten.GamepadID) int {
+	if g := b.cur.findGamepad(id); g != nil {
+		return g.axisCount
+	}
+	return 0
+}
+
+func (b *frameBackend) GamepadAxisValue(id ebiten.GamepadID, axis int) float64 {
+	if g := b.cur.findGamepad(id); g != nil && axis >= 0 && axis < g.axisCount {
+		return g.axes[axis]
+	}
+	return 0
+}
+
+func (b *frameBackend) IsGamepadButtonPressed(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
+	return b.cur.gamepadButtonIsPressed(id, button)
+}
+
+func (b *frameBackend) IsGamepadButtonJustPressed(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
+	return b.cur.gamepadButtonIsPressed(id, button) && !b.prev.gamepadButtonIsPressed(id, button)
+}
+
+func (b *frameBackend) IsGamepadButtonJustReleased(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
+	return !b.cur.gamepadButtonIsPressed(id, button) && b.prev.gamepadButtonIsPressed(id, button)
+}
+
+func (b *frameBackend) AppendJustPressedGamepadButtons(id ebiten.GamepadID, buttons []ebiten.GamepadButton) []ebiten.GamepadButton {
+	for button := ebiten.GamepadButton(0); button <= ebiten.GamepadButtonMax; button++ {
+		if b.IsGamepadButtonJustPressed(id, button) {
+			buttons = append(buttons, button)
+		}
+	}
+	return buttons
+}
+
+func (b *frameBackend) AppendJustReleasedGamepadButtons(id ebiten.GamepadID, buttons []ebiten.GamepadButton) []ebiten.GamepadButton {
+	for button := ebiten.GamepadButton(0); button <= ebiten.GamepadButtonMax; button++ {
+		if b.IsGamepadButtonJustReleased(id, button) {
+			buttons = append(buttons, button)
+		}
+	}
+	return buttons
+}
+
+func (b *frameBackend) IsStandardGamepadLayoutAvailable(id ebiten.GamepadID) bool {
+	g := b.cur.findGamepad(id)
+	return g != nil && g.standard
+}
+
+func (b *frameBackend) StandardGamepadAxisValue(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) float64 {
+	if g := b.cur.findGamepad(id); g != nil && axis >= 0 && axis <= ebiten.StandardGamepadAxisMax {
+		return g.standardAxes[axis]
+	}
+	return 0
+}
+
+func (b *frameBackend) IsStandardGamepadButtonPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	return b.cur.standardGamepadButtonIsPressed(id, button)
+}
+
+func (b *frameBackend) StandardGamepadButtonValue(id ebiten.GamepadID, button ebiten.StandardGamepadButton) float64 {
+	g := b.cur.findGamepad(id)
+	if g == nil || !g.standard || button < 0 || button > ebiten.StandardGamepadButtonMax {
+		return 0
+	}
+	return g.standardButtonValues[button]
+}
+
+func (b *frameBackend) IsStandardGamepadButtonJustPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	return b.cur.standardGamepadButtonIsPressed(id, button) && !b.prev.standardGamepadButtonIsPressed(id, button)
+}
+
+func (b *frameBackend) IsStandardGamepadButtonJustReleased(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	return !b.cur.standardGamepadButtonIsPressed(id, button) && b.prev.standardGamepadButtonIsPressed(id, button)
+}
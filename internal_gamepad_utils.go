@@ -30,6 +30,10 @@ type gamepadInfo struct {
 	axisCount      int
 	axisValues     [8]float64
 	prevAxisValues [8]float64
+
+	// The left and right trigger values of the standard layout gamepads.
+	triggerValues     [2]float64
+	prevTriggerValues [2]float64
 }
 
 func isDPadButton(code int) bool {
@@ -4,46 +4,63 @@ type keyKind uint8
 
 const (
 	keyKeyboard keyKind = iota
-	keyKeyboardWithCtrl
-	keyKeyboardWithShift
-	keyKeyboardWithCtrlShift
 	keyGamepad
 	keyGamepadLeftStick
 	keyGamepadRightStick
 	keyGamepadStickMotion
+	keyGamepadTrigger
 	keyMouse
-	keyMouseWithCtrl
-	keyMouseWithShift
-	keyMouseWithCtrlShift
 	keyMouseDrag
+	keyMouseSwipe
 	keyTouch
 	keyTouchDrag
+	keyTouchSwipe
 	keyWheel
-	keyWheelWithCtrl
-	keyWheelWithShift
-	keyWheelWithCtrlShift
 	keySimulated
+	keyChord
+	keySequence
+	keyMultiTap
+	keyHold
+	keyTap
+	keyAxis
+	keyVector2
+	keyTriggerThreshold
+	keyRepeat
 )
 
 func (k keyKind) device() DeviceKind {
 	switch k {
-	case keyKeyboard, keyKeyboardWithCtrl, keyKeyboardWithShift, keyKeyboardWithCtrlShift:
+	case keyKeyboard:
 		return KeyboardDevice
-	case keyGamepad, keyGamepadLeftStick, keyGamepadRightStick, keyGamepadStickMotion:
+	case keyGamepad, keyGamepadLeftStick, keyGamepadRightStick, keyGamepadStickMotion, keyGamepadTrigger:
 		return GamepadDevice
-	case keyMouse, keyMouseDrag:
+	case keyMouse, keyMouseDrag, keyMouseSwipe, keyWheel:
 		return MouseDevice
-	case keyWheel, keyWheelWithCtrl, keyWheelWithShift, keyWheelWithCtrlShift:
-		return MouseDevice
-	case keyMouseWithCtrl, keyMouseWithShift, keyMouseWithCtrlShift:
-		return MouseDevice | KeyboardDevice
-	case keyTouch, keyTouchDrag:
+	case keyTouch, keyTouchDrag, keyTouchSwipe:
 		return TouchDevice
 	default:
 		return KeyboardDevice
 	}
 }
 
+// keyDevice is like keyKind.device, but it also takes the key modifiers
+// and the composite key parts into account.
+func keyDevice(k Key) DeviceKind {
+	if keyIsComposite(k.kind) {
+		var d DeviceKind
+		for _, member := range getCompositeKey(k).keys {
+			d |= keyDevice(member)
+		}
+		return d
+	}
+	d := k.kind.device()
+	if k.mod != 0 && k.kind == keyMouse {
+		// A ctrl+click involves both keyboard and mouse devices.
+		d |= KeyboardDevice
+	}
+	return d
+}
+
 type touchCode int
 
 const (
@@ -51,6 +68,22 @@ const (
 	touchTap
 	touchLongTap
 	touchDrag
+	touchPinch
+	touchRotate
+)
+
+func touchCodeIsGesture(code touchCode) bool {
+	return code == touchPinch || code == touchRotate
+}
+
+type swipeCode int
+
+const (
+	swipeNone swipeCode = iota
+	swipeUp
+	swipeDown
+	swipeLeft
+	swipeRight
 )
 
 type wheelCode int
@@ -60,6 +93,9 @@ const (
 	wheelUp
 	wheelDown
 	wheelVertical
+	wheelLeft
+	wheelRight
+	wheelHorizontal
 )
 
 type stickCode int
@@ -78,20 +114,59 @@ const (
 	keyFlagHasPos keyKindFlag = 1 << iota
 	keyFlagNeedID
 	keyFlagHasDuration
+
+	// keyFlagComposite is set for the keys that are built from other keys.
+	// The composite key parts can be retrieved using getCompositeKey.
+	keyFlagComposite
+
+	// keyFlagStateful is set for the keys that need a per-handler state.
+	// See keyState for more info.
+	keyFlagStateful
 )
 
-func keyHasPos(k keyKind) bool      { return keyKindFlagTable[k]&keyFlagHasPos != 0 }
 func keyNeedID(k keyKind) bool      { return keyKindFlagTable[k]&keyFlagNeedID != 0 }
-func keyHasDuration(k keyKind) bool { return keyKindFlagTable[k]&keyFlagHasDuration != 0 }
+func keyIsComposite(k keyKind) bool { return keyKindFlagTable[k]&keyFlagComposite != 0 }
+func keyIsStateful(k keyKind) bool  { return keyKindFlagTable[k]&keyFlagStateful != 0 }
+
+// keyHasPos reports whether k activation has a position.
+// A chord has a position if any of its keys has it.
+// A sequence has a position if its last step key has it.
+// Other wrapper keys have a position if their wrapped key has it.
+func keyHasPos(k Key) bool {
+	switch k.kind {
+	case keySequence, keyMultiTap, keyHold, keyTap, keyRepeat:
+		keys := getCompositeKey(k).keys
+		return keyHasPos(keys[len(keys)-1])
+	case keyChord:
+		for _, member := range getCompositeKey(k).keys {
+			if keyHasPos(member) {
+				return true
+			}
+		}
+		return false
+	}
+	return keyKindFlagTable[k.kind]&keyFlagHasPos != 0
+}
+
+// keyHasDuration reports whether k activation has a press duration.
+// A chord has a duration only if all of its keys have it.
+func keyHasDuration(k Key) bool {
+	if k.kind == keyChord {
+		for _, member := range getCompositeKey(k).keys {
+			if !keyHasDuration(member) {
+				return false
+			}
+		}
+		return true
+	}
+	return keyKindFlagTable[k.kind]&keyFlagHasDuration != 0
+}
 
 // Using a 256-byte LUT to get a fast map-like lookup without a bound check.
 var keyKindFlagTable = [256]keyKindFlag{
 	keySimulated: keyFlagHasPos | keyFlagNeedID,
 
-	keyKeyboard:              keyFlagHasDuration,
-	keyKeyboardWithCtrl:      keyFlagHasDuration,
-	keyKeyboardWithShift:     keyFlagHasDuration,
-	keyKeyboardWithCtrlShift: keyFlagHasDuration,
+	keyKeyboard: keyFlagHasDuration,
 
 	keyGamepad:           keyFlagNeedID,
 	keyGamepadLeftStick:  keyFlagNeedID,
@@ -99,14 +174,89 @@ var keyKindFlagTable = [256]keyKindFlag{
 
 	keyGamepadStickMotion: keyFlagHasPos | keyFlagNeedID,
 
-	keyMouse:              keyFlagHasPos,
-	keyMouseWithCtrl:      keyFlagHasPos,
-	keyMouseWithShift:     keyFlagHasPos,
-	keyMouseWithCtrlShift: keyFlagHasPos,
-	keyMouseDrag:          keyFlagHasPos,
-	keyTouch:              keyFlagHasPos,
-	keyWheel:              keyFlagHasPos,
-	keyWheelWithCtrl:      keyFlagHasPos,
-	keyWheelWithShift:     keyFlagHasPos,
-	keyWheelWithCtrlShift: keyFlagHasPos,
+	// The trigger keys hysteresis uses a lazily updated state, see triggerState.
+	keyGamepadTrigger:   keyFlagNeedID,
+	keyTriggerThreshold: keyFlagNeedID | keyFlagComposite,
+
+	keyMouse:      keyFlagHasPos,
+	keyMouseDrag:  keyFlagHasPos,
+	keyMouseSwipe: keyFlagHasPos,
+	keyTouch:      keyFlagHasPos,
+	keyTouchSwipe: keyFlagHasPos,
+	keyWheel:      keyFlagHasPos,
+
+	// The chord flags depend on its keys, see keyHasPos and keyHasDuration.
+	// Simulated chord events are always bound to the player ID,
+	// since the chord may include the gamepad keys.
+	keyChord: keyFlagNeedID | keyFlagComposite,
+
+	keySequence: keyFlagNeedID | keyFlagComposite | keyFlagStateful,
+	keyMultiTap: keyFlagNeedID | keyFlagComposite | keyFlagStateful,
+	keyHold:     keyFlagNeedID | keyFlagComposite | keyFlagStateful,
+	keyTap:      keyFlagNeedID | keyFlagComposite | keyFlagStateful,
+	keyRepeat:   keyFlagNeedID | keyFlagComposite | keyFlagStateful,
+
+	// The axis keys value is stored in the Pos, like with the stick motion keys.
+	keyAxis:    keyFlagHasPos | keyFlagNeedID | keyFlagComposite | keyFlagStateful,
+	keyVector2: keyFlagHasPos | keyFlagNeedID | keyFlagComposite | keyFlagStateful,
+}
+
+// keyIsMoreSpecific reports whether k activation implies the other key activation,
+// while the opposite is not true.
+// For example, ctrl+s is more specific than s and q+e is more specific than q.
+func keyIsMoreSpecific(k, other Key) bool {
+	if k == other {
+		return false
+	}
+	if k.kind == keyChord {
+		keys := getCompositeKey(k).keys
+		if other.kind == keyChord {
+			// A chord is more specific than another chord if it includes all of its keys.
+			for _, otherMember := range getCompositeKey(other).keys {
+				if !keySliceContains(keys, otherMember) {
+					return false
+				}
+			}
+			return true
+		}
+		for _, member := range keys {
+			if member == other || keyIsMoreSpecific(member, other) {
+				return true
+			}
+		}
+		return false
+	}
+	return k.kind == other.kind && k.code == other.code &&
+		k.mod&other.mod == other.mod
+}
+
+// collectSpecificKeys maps every keymap key to a list of
+// the more specific keymap keys.
+func collectSpecificKeys(keymap Keymap) map[Key][]Key {
+	var boundKeys []Key
+	for _, keys := range keymap {
+		for _, k := range keys {
+			if !keySliceContains(boundKeys, k) {
+				boundKeys = append(boundKeys, k)
+			}
+		}
+	}
+	result := make(map[Key][]Key)
+	for _, k := range boundKeys {
+		for _, other := range boundKeys {
+			if keyIsMoreSpecific(other, k) {
+				result[k] = append(result[k], other)
+			}
+		}
+	}
+	return result
+}
+
+func keySliceContains(keys []Key, k Key) bool {
+	for _, other := range keys {
+		if other == k {
+			return true
+		}
+	}
+	return false
 }
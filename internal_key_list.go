@@ -68,6 +68,7 @@ var allKeys = []Key{
 	KeyGamepadLStickMotion,
 	KeyGamepadLStickRight,
 	KeyGamepadLStickUp,
+	KeyGamepadLTrigger,
 	KeyGamepadR1,
 	KeyGamepadR2,
 	KeyGamepadRight,
@@ -77,6 +78,7 @@ var allKeys = []Key{
 	KeyGamepadRStickMotion,
 	KeyGamepadRStickRight,
 	KeyGamepadRStickUp,
+	KeyGamepadRTrigger,
 	KeyGamepadStart,
 	KeyGamepadUp,
 	KeyGamepadX,
@@ -90,13 +92,22 @@ var allKeys = []Key{
 	KeyL,
 	KeyLeft,
 	KeyM,
+	KeyMeta,
+	KeyMetaLeft,
+	KeyMetaRight,
 	KeyMinus,
 	KeyMouseBack,
 	KeyMouseForward,
 	KeyMouseLeft,
 	KeyMouseLeftDrag,
 	KeyMouseMiddle,
+	KeyMouseMiddleDrag,
 	KeyMouseRight,
+	KeyMouseRightDrag,
+	KeyMouseSwipeDown,
+	KeyMouseSwipeLeft,
+	KeyMouseSwipeRight,
+	KeyMouseSwipeUp,
 	KeyN,
 	KeyNumLock,
 	KeyNum0,
@@ -138,12 +149,21 @@ var allKeys = []Key{
 	KeyTab,
 	KeyTouchDrag,
 	KeyTouchLongTap,
+	KeyTouchPinch,
+	KeyTouchRotate,
+	KeyTouchSwipeDown,
+	KeyTouchSwipeLeft,
+	KeyTouchSwipeRight,
+	KeyTouchSwipeUp,
 	KeyTouchTap,
 	KeyU,
 	KeyUp,
 	KeyV,
 	KeyW,
 	KeyWheelDown,
+	KeyWheelHorizontal,
+	KeyWheelLeft,
+	KeyWheelRight,
 	KeyWheelUp,
 	KeyWheelVertical,
 	KeyX,
@@ -0,0 +1,397 @@
+package input
+
+// keyState is a per-handler state of the key that needs to observe
+// the input during several frames, like a sequence key.
+//
+// The handlers with stateful keys are registered in the System,
+// their key states are updated during every System.Update call.
+// This way, the state doesn't depend on whether the action
+// was queried during some frame or not.
+type keyState struct {
+	key Key
+
+	justPressed bool
+	pos         Vec
+
+	// history holds the recently performed sequence steps.
+	// Its length never exceeds the number of the sequence steps.
+	history []sequenceInput
+
+	// taps is a number of multi-tap key taps performed so far.
+	// For multi-tap keys, timer is the time passed since the last tap.
+	// For hold and tap keys, timer is the time the wrapped key is being held.
+	// For repeat keys, timer is the time passed since the last repeat.
+	taps  int
+	timer float64
+
+	// Hold and tap keys state.
+	// The pressed and justReleased are also used by the axis keys.
+	held         bool
+	fired        bool
+	pressed      bool
+	justReleased bool
+
+	// Axis keys state: the current value and the ticks
+	// when every axis key was pressed for the last time.
+	value       Vec
+	memberTicks []int
+}
+
+type sequenceInput struct {
+	key  Key
+	tick int
+}
+
+func (h *Handler) initKeyStates() {
+	h.keyStates = nil
+	h.keyStateList = h.keyStateList[:0]
+	for _, keys := range h.keymap {
+		for _, k := range keys {
+			h.collectKeyStates(k)
+		}
+	}
+	h.register()
+}
+
+// register adds the handler to the System update list if it needs
+// a per-frame update: it has stateful keys, a virtual gamepad or an input buffer.
+// If the handler doesn't need the updates anymore, it's removed from that list.
+func (h *Handler) register() {
+	needUpdate := !h.removed &&
+		(len(h.keyStateList) != 0 || h.virtualGamepad != nil || h.bufferTicks != 0)
+	if needUpdate == h.registered {
+		return
+	}
+	h.registered = needUpdate
+	if needUpdate {
+		h.sys.handlers = append(h.sys.handlers, h)
+		return
+	}
+	for i, other := range h.sys.handlers {
+		if other == h {
+			h.sys.handlers = append(h.sys.handlers[:i], h.sys.handlers[i+1:]...)
+			break
+		}
+	}
+}
+
+func (h *Handler) collectKeyStates(k Key) {
+	if _, ok := h.keyStates[k]; ok {
+		return
+	}
+	// The parts are collected first, so their states
+	// are updated before the state of the key that uses them.
+	if keyIsComposite(k.kind) {
+		for _, member := range getCompositeKey(k).keys {
+			h.collectKeyStates(member)
+		}
+	}
+	if !keyIsStateful(k.kind) {
+		return
+	}
+	if h.keyStates == nil {
+		h.keyStates = make(map[Key]*keyState)
+	}
+	st := &keyState{key: k}
+	h.keyStates[k] = st
+	h.keyStateList = append(h.keyStateList, st)
+}
+
+func (h *Handler) updateKeyStates(delta float64) {
+	h.tick++
+	for _, st := range h.keyStateList {
+		st.justPressed = false
+		switch st.key.kind {
+		case keySequence:
+			h.updateSequenceState(st)
+		case keyMultiTap:
+			h.updateMultiTapState(st, delta)
+		case keyHold, keyTap:
+			h.updateHoldState(st, delta)
+		case keyAxis, keyVector2:
+			h.updateAxisState(st)
+		case keyRepeat:
+			h.updateRepeatState(st, delta)
+		}
+	}
+}
+
+func (h *Handler) keyStateIsJustPressed(k Key) bool {
+	st := h.keyStates[k]
+	return st != nil && st.justPressed
+}
+
+// keyIsPressedOrSimulated is like keyIsPressed, but it also checks the simulated events.
+func (h *Handler) keyIsPressedOrSimulated(k Key) bool {
+	return h.keyIsPressed(k) || h.simulatedKeyIsPressed(k)
+}
+
+// keyJustPressedPos is like keyIsJustPressed, but it also
+// checks the simulated events and returns the key pos.
+func (h *Handler) keyJustPressedPos(k Key) (Vec, bool) {
+	if info, status := h.pressedSimulatedKeyInfo(true, k); status == bool3true {
+		return info.Pos, true
+	}
+	if h.keyIsJustPressed(k) {
+		return h.getKeyPos(k), true
+	}
+	return Vec{}, false
+}
+
+func (h *Handler) updateSequenceState(st *keyState) {
+	seq := getCompositeKey(st.key)
+
+	// Every press of the sequence own keys is recorded, even if it doesn't
+	// match the expected step; this resets the sequence progress.
+	// The keys that are not a part of the sequence are not tracked at all,
+	// so they never reset it, even if they belong to the same device.
+	// The unique keys are checked only once per frame.
+	for i, k := range seq.keys {
+		if keySliceContains(seq.keys[:i], k) {
+			continue
+		}
+		pos, ok := h.keyJustPressedPos(k)
+		if !ok {
+			continue
+		}
+		if len(st.history) == len(seq.keys) {
+			copy(st.history, st.history[1:])
+			st.history = st.history[:len(st.history)-1]
+		}
+		st.history = append(st.history, sequenceInput{key: k, tick: h.tick})
+		st.pos = pos
+	}
+
+	if len(st.history) != len(seq.keys) {
+		return
+	}
+	for i, input := range st.history {
+		if input.key != seq.keys[i] {
+			return
+		}
+		if i == 0 || seq.windows[i] == 0 {
+			continue
+		}
+		if input.tick-st.history[i-1].tick > seq.windows[i] {
+			return
+		}
+	}
+	st.justPressed = true
+	st.history = st.history[:0]
+}
+
+func (h *Handler) updateMultiTapState(st *keyState, delta float64) {
+	data := getCompositeKey(st.key)
+	interval := data.interval
+	if interval == 0 {
+		interval = h.sys.gestures.MultiTapInterval
+	}
+
+	if st.taps != 0 {
+		st.timer += delta
+		if st.timer > interval {
+			st.taps = 0
+		}
+	}
+
+	pos, ok := h.keyJustPressedPos(data.keys[0])
+	if !ok {
+		return
+	}
+	st.taps++
+	st.timer = 0
+	st.pos = pos
+	if st.taps == data.taps {
+		st.justPressed = true
+		st.taps = 0
+	}
+}
+
+func (h *Handler) updateHoldState(st *keyState, delta float64) {
+	data := getCompositeKey(st.key)
+	k := data.keys[0]
+
+	st.justReleased = false
+	if !h.keyIsPressedOrSimulated(k) {
+		if st.held {
+			switch {
+			case st.key.kind == keyTap && !st.fired:
+				st.justPressed = true
+			case st.key.kind == keyHold && st.fired:
+				st.justReleased = true
+			}
+		}
+		st.held = false
+		st.fired = false
+		st.pressed = false
+		st.timer = 0
+		return
+	}
+
+	if st.held {
+		st.timer += delta
+	}
+	st.held = true
+	st.pos = h.getKeyPos(k)
+	// For the tap keys, fired means that it's too late for a tap.
+	if !st.fired && holdTimeReached(st.timer, data.duration) {
+		st.fired = true
+		st.justPressed = st.key.kind == keyHold
+	}
+	st.pressed = st.fired && st.key.kind == keyHold
+}
+
+func (h *Handler) updateRepeatState(st *keyState, delta float64) {
+	data := getCompositeKey(st.key)
+	k := data.keys[0]
+
+	st.justReleased = false
+	if !h.keyIsPressedOrSimulated(k) {
+		st.justReleased = st.pressed
+		st.pressed = false
+		st.fired = false
+		st.timer = 0
+		return
+	}
+
+	st.pos = h.getKeyPos(k)
+	if !st.pressed {
+		st.pressed = true
+		st.justPressed = true
+		return
+	}
+
+	// For the repeat keys, fired means that the initial delay has passed.
+	st.timer += delta
+	threshold := data.duration
+	if st.fired {
+		threshold = data.interval
+	}
+	if holdTimeReached(st.timer, threshold) {
+		// There is at most one repeat per frame.
+		// The remaining time is kept to make the repeat rate stable.
+		st.timer -= threshold
+		st.fired = true
+		st.justPressed = true
+	}
+}
+
+func (h *Handler) updateAxisState(st *keyState) {
+	keys := getCompositeKey(st.key).keys
+	if st.memberTicks == nil {
+		st.memberTicks = make([]int, len(keys))
+	}
+	for i, k := range keys {
+		if _, ok := h.keyJustPressedPos(k); ok {
+			st.memberTicks[i] = h.tick
+		}
+	}
+
+	var value Vec
+	if st.key.kind == keyAxis {
+		value.X = h.axisValue(st, 0, 1)
+	} else {
+		value.X = h.axisValue(st, 2, 3)
+		value.Y = h.axisValue(st, 0, 1)
+		if l := vecLen(value); !h.AxisRawDiagonals && l > 1 {
+			value.X /= l
+			value.Y /= l
+		}
+	}
+
+	wasPressed := st.pressed
+	st.value = value
+	st.pressed = value != (Vec{})
+	st.justPressed = st.pressed && !wasPressed
+	st.justReleased = !st.pressed && wasPressed
+	if st.pressed {
+		st.pos = value
+	}
+}
+
+// axisValue combines two opposing axis keys, neg and pos are their indexes.
+func (h *Handler) axisValue(st *keyState, neg, pos int) float64 {
+	keys := getCompositeKey(st.key).keys
+	negValue := h.keyValue(keys[neg])
+	posValue := h.keyValue(keys[pos])
+	if h.AxisLastPressedWins && negValue != 0 && posValue != 0 {
+		// The ties are possible when both keys are pressed during the same frame.
+		if st.memberTicks[neg] > st.memberTicks[pos] {
+			return -negValue
+		}
+		if st.memberTicks[pos] > st.memberTicks[neg] {
+			return posValue
+		}
+	}
+	return posValue - negValue
+}
+
+// triggerState is a lazily updated trigger key state.
+// Like the stickDPadState, it doesn't need the handler to be updated by the system.
+type triggerState struct {
+	// tick is a system tick when this state was updated.
+	tick int
+
+	pressed     bool
+	prevPressed bool
+}
+
+// triggerState returns the up-to-date state of the trigger key k.
+// The state is computed once per system tick.
+func (h *Handler) triggerState(k Key) *triggerState {
+	st := h.triggerStates[k]
+	if st == nil {
+		if h.triggerStates == nil {
+			h.triggerStates = make(map[Key]*triggerState)
+		}
+		st = &triggerState{}
+		h.triggerStates[k] = st
+	}
+	if st.tick == h.sys.tick {
+		return st
+	}
+
+	button := k
+	threshold := h.TriggerThreshold
+	if k.kind == keyTriggerThreshold {
+		data := getCompositeKey(k)
+		button = data.keys[0]
+		threshold = data.threshold
+	}
+
+	prev := st.pressed
+	if st.tick != h.sys.tick-1 {
+		// The state was not computed during the previous frame,
+		// so its hysteresis is unknown.
+		prev = h.triggerValue(button, true) >= threshold
+	}
+	value := h.triggerValue(button, false)
+	st.tick = h.sys.tick
+	st.prevPressed = prev
+	if prev {
+		st.pressed = value > 0 && value >= threshold-h.TriggerHysteresis
+	} else {
+		st.pressed = value >= threshold
+	}
+	return st
+}
+
+func (h *Handler) keyHoldProgress(k Key) float64 {
+	st := h.keyStates[k]
+	if st == nil || !st.held {
+		return 0
+	}
+	duration := getCompositeKey(k).duration
+	if holdTimeReached(st.timer, duration) {
+		return 1
+	}
+	return st.timer / duration
+}
+
+func holdTimeReached(t, duration float64) bool {
+	// The time is accumulated from the deltas, so it's
+	// a subject to the floating-point rounding errors.
+	// An epsilon makes 10 deltas of 0.1 add up to 1.0.
+	const epsilon = 1e-9
+	return t+epsilon >= duration
+}
@@ -0,0 +1,386 @@
+package input
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// The recording file layout (all numbers are little-endian):
+//
+//	header: magic [4]byte "EIRF" | version uint16
+//	frame:  payload size uint32 | payload
+//
+// See recordedFrame.encode for the frame payload layout.
+const (
+	recordMagic   = "EIRF"
+	recordVersion = 1
+
+	// Even with all keys pressed and many gamepads connected
+	// a frame is much smaller than that.
+	maxRecordFrameSize = 64 * 1024
+)
+
+// recordedFrame is a resolved devices state of a single frame.
+type recordedFrame struct {
+	delta        float64
+	keys         [ebiten.KeyMax + 1]bool
+	mouseButtons [ebiten.MouseButtonMax + 1]bool
+	cursorX      int
+	cursorY      int
+	wheelX       float64
+	wheelY       float64
+	touches      []recordedTouch
+	gamepads     []recordedGamepad
+}
+
+type recordedTouch struct {
+	id ebiten.TouchID
+	x  int
+	y  int
+}
+
+type recordedGamepad struct {
+	id       ebiten.GamepadID
+	name     string
+	standard bool
+
+	axisCount int
+	axes      [len(gamepadInfo{}.axisValues)]float64
+	buttons   [ebiten.GamepadButtonMax + 1]bool
+
+	standardAxes         [ebiten.StandardGamepadAxisMax + 1]float64
+	standardButtons      [ebiten.StandardGamepadButtonMax + 1]bool
+	standardButtonValues [ebiten.StandardGamepadButtonMax + 1]float64
+}
+
+func (f *recordedFrame) reset() {
+	touches := f.touches[:0]
+	gamepads := f.gamepads[:0]
+	*f = recordedFrame{}
+	f.touches = touches
+	f.gamepads = gamepads
+}
+
+func (f *recordedFrame) copyFrom(other *recordedFrame) {
+	touches := append(f.touches[:0], other.touches...)
+	gamepads := append(f.gamepads[:0], other.gamepads...)
+	*f = *other
+	f.touches = touches
+	f.gamepads = gamepads
+}
+
+func (f *recordedFrame) capture(b Backend) {
+	f.reset()
+
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		f.keys[k] = b.IsKeyPressed(k)
+	}
+	for button := ebiten.MouseButton(0); button <= ebiten.MouseButtonMax; button++ {
+		f.mouseButtons[button] = b.IsMouseButtonPressed(button)
+	}
+	f.cursorX, f.cursorY = b.CursorPosition()
+	f.wheelX, f.wheelY = b.Wheel()
+
+	var touchIDs [8]ebiten.TouchID
+	for _, id := range b.AppendTouchIDs(touchIDs[:0]) {
+		x, y := b.TouchPosition(id)
+		f.touches = append(f.touches, recordedTouch{id: id, x: x, y: y})
+	}
+
+	var gamepadIDs [8]ebiten.GamepadID
+	for _, id := range b.AppendGamepadIDs(gamepadIDs[:0]) {
+		g := recordedGamepad{
+			id:        id,
+			name:      b.GamepadName(id),
+			standard:  b.IsStandardGamepadLayoutAvailable(id),
+			axisCount: minOf(b.GamepadAxisCount(id), len(recordedGamepad{}.axes)),
+		}
+		for axis := 0; axis < g.axisCount; axis++ {
+			g.axes[axis] = b.GamepadAxisValue(id, axis)
+		}
+		for button := ebiten.GamepadButton(0); button <= ebiten.GamepadButtonMax; button++ {
+			g.buttons[button] = b.IsGamepadButtonPressed(id, button)
+		}
+		if g.standard {
+			for axis := ebiten.StandardGamepadAxis(0); axis <= ebiten.StandardGamepadAxisMax; axis++ {
+				g.standardAxes[axis] = b.StandardGamepadAxisValue(id, axis)
+			}
+			for button := ebiten.StandardGamepadButton(0); button <= ebiten.StandardGamepadButtonMax; button++ {
+				g.standardButtons[button] = b.IsStandardGamepadButtonPressed(id, button)
+				g.standardButtonValues[button] = standardGamepadButtonValue(b, id, button)
+			}
+		}
+		f.gamepads = append(f.gamepads, g)
+	}
+}
+
+// encode appends the frame payload to the dst.
+//
+// The payload layout:
+//
+//	delta:    time delta float64
+//	keys:     count uint16 | codes []uint16
+//	mouse:    buttons mask uint8 | cursor x, y int32 | wheel x, y float64
+//	touches:  count uint8 | (id int32 | x, y int32)...
+//	gamepads: count uint8 | gamepad...
+//
+// Every gamepad is encoded as:
+//
+//	id int32 | name size uint8 | name []byte | standard uint8 |
+//	axis count uint8 | axes []float64 | buttons mask uint32 |
+//	(standard axes []float64 | standard buttons mask uint32 | analog buttons) if standard
+//
+// Most of the button values are either 0 or 1, so only the other
+// (analog) values are encoded as count uint8 | (button uint8 | value float64)...
+// The digital values are restored from the buttons mask.
+func (f *recordedFrame) encode(dst []byte) []byte {
+	dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(f.delta))
+
+	numKeys := 0
+	for _, pressed := range f.keys {
+		if pressed {
+			numKeys++
+		}
+	}
+	dst = binary.LittleEndian.AppendUint16(dst, uint16(numKeys))
+	for k, pressed := range f.keys {
+		if pressed {
+			dst = binary.LittleEndian.AppendUint16(dst, uint16(k))
+		}
+	}
+
+	dst = append(dst, uint8(encodeBoolMask(f.mouseButtons[:])))
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(int32(f.cursorX)))
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(int32(f.cursorY)))
+	dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(f.wheelX))
+	dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(f.wheelY))
+
+	dst = append(dst, uint8(len(f.touches)))
+	for _, t := range f.touches {
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(int32(t.id)))
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(int32(t.x)))
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(int32(t.y)))
+	}
+
+	dst = append(dst, uint8(len(f.gamepads)))
+	for i := range f.gamepads {
+		g := &f.gamepads[i]
+		name := g.name
+		if len(name) > math.MaxUint8 {
+			name = name[:math.MaxUint8]
+		}
+		dst = binary.LittleEndian.AppendUint32(dst, uint32(int32(g.id)))
+		dst = append(dst, uint8(len(name)))
+		dst = append(dst, name...)
+		if g.standard {
+			dst = append(dst, 1)
+		} else {
+			dst = append(dst, 0)
+		}
+		dst = append(dst, uint8(g.axisCount))
+		for _, v := range g.axes[:g.axisCount] {
+			dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(v))
+		}
+		dst = binary.LittleEndian.AppendUint32(dst, encodeBoolMask(g.buttons[:]))
+		if g.standard {
+			for _, v := range g.standardAxes {
+				dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(v))
+			}
+			dst = binary.LittleEndian.AppendUint32(dst, encodeBoolMask(g.standardButtons[:]))
+			numAnalog := 0
+			for _, v := range g.standardButtonValues {
+				if v != 0 && v != 1 {
+					numAnalog++
+				}
+			}
+			dst = append(dst, uint8(numAnalog))
+			for button, v := range g.standardButtonValues {
+				if v != 0 && v != 1 {
+					dst = append(dst, uint8(button))
+					dst = binary.LittleEndian.AppendUint64(dst, math.Float64bits(v))
+				}
+			}
+		}
+	}
+
+	return dst
+}
+
+var errBadRecordFrame = errors.New("malformed recording frame")
+
+// decode is the inverse of encode.
+func (f *recordedFrame) decode(data []byte) error {
+	f.reset()
+	r := frameReader{data: data}
+
+	f.delta = r.float64()
+
+	numKeys := int(r.uint16())
+	for i := 0; i < numKeys; i++ {
+		k := ebiten.Key(r.uint16())
+		if k < 0 || k > ebiten.KeyMax {
+			return errBadRecordFrame
+		}
+		f.keys[k] = true
+	}
+
+	decodeBoolMask(f.mouseButtons[:], uint32(r.uint8()))
+	f.cursorX = int(int32(r.uint32()))
+	f.cursorY = int(int32(r.uint32()))
+	f.wheelX = r.float64()
+	f.wheelY = r.float64()
+
+	numTouches := int(r.uint8())
+	for i := 0; i < numTouches; i++ {
+		f.touches = append(f.touches, recordedTouch{
+			id: ebiten.TouchID(int32(r.uint32())),
+			x:  int(int32(r.uint32())),
+			y:  int(int32(r.uint32())),
+		})
+	}
+
+	numGamepads := int(r.uint8())
+	for i := 0; i < numGamepads; i++ {
+		var g recordedGamepad
+		g.id = ebiten.GamepadID(int32(r.uint32()))
+		g.name = string(r.bytes(int(r.uint8())))
+		g.standard = r.uint8() != 0
+		g.axisCount = int(r.uint8())
+		if g.axisCount > len(g.axes) {
+			return errBadRecordFrame
+		}
+		for axis := 0; axis < g.axisCount; axis++ {
+			g.axes[axis] = r.float64()
+		}
+		decodeBoolMask(g.buttons[:], r.uint32())
+		if g.standard {
+			for axis := range g.standardAxes {
+				g.standardAxes[axis] = r.float64()
+			}
+			decodeBoolMask(g.standardButtons[:], r.uint32())
+			for button, pressed := range g.standardButtons {
+				if pressed {
+					g.standardButtonValues[button] = 1
+				}
+			}
+			numAnalog := int(r.uint8())
+			for i := 0; i < numAnalog; i++ {
+				button := int(r.uint8())
+				if button >= len(g.standardButtonValues) {
+					return errBadRecordFrame
+				}
+				g.standardButtonValues[button] = r.float64()
+			}
+		}
+		f.gamepads = append(f.gamepads, g)
+	}
+
+	if r.err || len(r.data) != 0 {
+		return errBadRecordFrame
+	}
+	return nil
+}
+
+func (f *recordedFrame) findTouch(id ebiten.TouchID) *recordedTouch {
+	for i := range f.touches {
+		if f.touches[i].id == id {
+			return &f.touches[i]
+		}
+	}
+	return nil
+}
+
+func (f *recordedFrame) findGamepad(id ebiten.GamepadID) *recordedGamepad {
+	for i := range f.gamepads {
+		if f.gamepads[i].id == id {
+			return &f.gamepads[i]
+		}
+	}
+	return nil
+}
+
+func (f *recordedFrame) gamepadButtonIsPressed(id ebiten.GamepadID, button ebiten.GamepadButton) bool {
+	g := f.findGamepad(id)
+	if g == nil || button < 0 || button > ebiten.GamepadButtonMax {
+		return false
+	}
+	return g.buttons[button]
+}
+
+func (f *recordedFrame) standardGamepadButtonIsPressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	g := f.findGamepad(id)
+	if g == nil || !g.standard || button < 0 || button > ebiten.StandardGamepadButtonMax {
+		return false
+	}
+	return g.standardButtons[button]
+}
+
+func encodeBoolMask(values []bool) uint32 {
+	mask := uint32(0)
+	for i, v := range values {
+		if v {
+			mask |= 1 << i
+		}
+	}
+	return mask
+}
+
+func decodeBoolMask(dst []bool, mask uint32) {
+	for i := range dst {
+		dst[i] = mask&(1<<i) != 0
+	}
+}
+
+// frameReader is a simple bytes decoder.
+// Instead of returning an error from every method,
+// it sets the err flag that should be checked in the end.
+type frameReader struct {
+	data []byte
+	err  bool
+}
+
+func (r *frameReader) bytes(n int) []byte {
+	if len(r.data) < n {
+		r.err = true
+		r.data = nil
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *frameReader) uint8() uint8 {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *frameReader) uint16() uint16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(b)
+}
+
+func (r *frameReader) uint32() uint32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (r *frameReader) float64() float64 {
+	b := r.bytes(8)
+	if b == nil {
+		return 0
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
@@ -0,0 +1,68 @@
+package input
+
+import (
+	"math"
+)
+
+// The swipe shape thresholds.
+// The distance and speed thresholds are configured via GestureConfig.
+const (
+	// swipeMaxAxisRatio limits the swipe deviation from its direction axis.
+	// 0.5 permits a deviation of ~26.5 degrees.
+	swipeMaxAxisRatio = 0.5
+
+	// swipeMinStraightness is a min ratio of the start-to-end distance
+	// to the actual traveled distance. A perfectly straight line has 1.0 ratio.
+	swipeMinStraightness = 0.8
+)
+
+// swipeInfo describes a swipe that happened during this frame.
+// The code is swipeNone if there was no swipe.
+type swipeInfo struct {
+	code     swipeCode
+	startPos Vec
+	endPos   Vec
+	velocity Vec
+}
+
+// detectSwipe checks whether a released drag was a swipe.
+// The pathLen is a traveled distance and the duration is a drag time in seconds.
+func detectSwipe(config *GestureConfig, startPos, endPos Vec, pathLen, duration float64) swipeInfo {
+	if duration <= 0 {
+		return swipeInfo{}
+	}
+	dx := endPos.X - startPos.X
+	dy := endPos.Y - startPos.Y
+	dist := math.Hypot(dx, dy)
+	if dist < config.SwipeMinDistance || dist/duration < config.SwipeMinSpeed {
+		return swipeInfo{}
+	}
+	if pathLen > 0 && dist/pathLen < swipeMinStraightness {
+		return swipeInfo{}
+	}
+
+	var code swipeCode
+	switch {
+	case math.Abs(dy) <= math.Abs(dx)*swipeMaxAxisRatio:
+		code = swipeRight
+		if dx < 0 {
+			code = swipeLeft
+		}
+	case math.Abs(dx) <= math.Abs(dy)*swipeMaxAxisRatio:
+		// The Y axis points down.
+		code = swipeDown
+		if dy < 0 {
+			code = swipeUp
+		}
+	default:
+		// A diagonal movement.
+		return swipeInfo{}
+	}
+
+	return swipeInfo{
+		code:     code,
+		startPos: startPos,
+		endPos:   endPos,
+		velocity: Vec{X: dx / duration, Y: dy / duration},
+	}
+}
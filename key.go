@@ -1,6 +1,8 @@
 package input
 
 import (
+	"strconv"
+
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
@@ -13,84 +15,440 @@ import (
 type Key struct {
 	code int
 	kind keyKind
+	mod  KeyModifier
 	name string
 }
 
 func (k Key) String() string {
-	ctrlMod := false
-	shiftMod := false
-	switch k.kind {
-	case keyKeyboardWithCtrlShift, keyMouseWithCtrlShift, keyWheelWithCtrlShift:
-		ctrlMod = true
-		shiftMod = true
-	case keyKeyboardWithCtrl, keyMouseWithCtrl, keyWheelWithCtrl:
-		ctrlMod = true
-	case keyKeyboardWithShift, keyMouseWithShift, keyWheelWithShift:
-		shiftMod = true
-	}
-	name := k.name
-	if shiftMod {
-		name = "shift+" + name
-	}
-	if ctrlMod {
-		name = "ctrl+" + name
-	}
-	return name
+	return k.mod.String() + k.name
 }
 
+// KeyModifier is a bit mask of the modifier keys that
+// should be pressed in order to activate the key.
+//
+// Modifiers can be combined: ModControl|ModAlt will require
+// both ctrl and alt keys to be pressed.
 type KeyModifier uint8
 
 const (
-	ModUnknown KeyModifier = iota
-	ModControl
-	ModShift
-	ModControlShift
+	ModUnknown KeyModifier = 0
+
+	ModControl KeyModifier = 1 << 0
+	ModShift   KeyModifier = 1 << 1
+	ModAlt     KeyModifier = 1 << 2
+
+	// ModMeta is a Cmd key on macOS and a Super (Windows) key on other systems.
+	ModMeta KeyModifier = 1 << 3
+
+	ModControlShift = ModControl | ModShift
+
+	modAll = ModControl | ModShift | ModAlt | ModMeta
 )
 
+// String returns the modifiers prefix for the key name.
+// Every modifier is followed by "+", so ModControl|ModShift
+// is printed as "ctrl+shift+".
+func (mod KeyModifier) String() string {
+	if mod == 0 {
+		return ""
+	}
+	s := ""
+	if mod&ModControl != 0 {
+		s += "ctrl+"
+	}
+	if mod&ModShift != 0 {
+		s += "shift+"
+	}
+	if mod&ModAlt != 0 {
+		s += "alt+"
+	}
+	if mod&ModMeta != 0 {
+		s += "meta+"
+	}
+	return s
+}
+
 // KeyWithModifier turns k into a combined modifier+k key.
 // For instance, KeyUp+ModControl will trigger an action
 // only if both of these keys are being pressed.
+//
+// The mod can be any combination of the modifiers, like ModControl|ModAlt.
+// If k already has some modifiers, they're combined with mod.
 func KeyWithModifier(k Key, mod KeyModifier) Key {
+	if mod == ModUnknown || mod&^modAll != 0 {
+		panic("unexpected key modifier")
+	}
 	switch k.kind {
-	case keyKeyboard:
-		switch mod {
-		case ModControl:
-			k.kind = keyKeyboardWithCtrl
-		case ModShift:
-			k.kind = keyKeyboardWithShift
-		case ModControlShift:
-			k.kind = keyKeyboardWithCtrlShift
-		default:
-			panic("unexpected keyboard key modifier")
-		}
-	case keyMouse:
-		switch mod {
-		case ModControl:
-			k.kind = keyMouseWithCtrl
-		case ModShift:
-			k.kind = keyMouseWithShift
-		case ModControlShift:
-			k.kind = keyMouseWithCtrlShift
-		default:
-			panic("unexpected mouse key modifier")
-		}
-	case keyWheel:
-		switch mod {
-		case ModControl:
-			k.kind = keyWheelWithCtrl
-		case ModShift:
-			k.kind = keyWheelWithShift
-		case ModControlShift:
-			k.kind = keyWheelWithCtrlShift
-		default:
-			panic("unexpected wheel key modifier")
-		}
+	case keyKeyboard, keyMouse, keyWheel:
+		k.mod |= mod
 	default:
 		panic("only keyboard and mouse keys support modifiers")
 	}
 	return k
 }
 
+// KeyChord combines several keys into a single chord key.
+// For instance, KeyChord(KeyGamepadL1, KeyGamepadA) will trigger an action
+// only if both of these keys are being pressed.
+//
+// Unlike KeyWithModifier, any keys can be combined, even the keys
+// of different devices: KeyChord(KeyShift, KeyMouseRight) is a valid chord.
+//
+// The chord is pressed while all of its keys are pressed.
+// It's "just pressed" during the frame when the last of its keys was pressed,
+// the order in which the keys are pressed is not important.
+// It's "just released" when any of its keys is released after the
+// chord was pressed; see Handler.ActionIsJustReleased for the supported key types.
+//
+// The chord name is its key names joined by "+", like "gamepad_l1+gamepad_a".
+// Chord keys are comparable: chords built from the same keys in the same order are equal.
+//
+// The chord keys can't have modifiers, since the chord name would be ambiguous.
+// Use the modifier keys as the chord members instead:
+// KeyChord(KeyControl, KeyS, KeyMouseLeft) instead of KeyChord(KeyWithModifier(KeyS, ModControl), KeyMouseLeft).
+//
+// Nested chords are flattened.
+// It panics if there are less than two keys, some of them are duplicated or have modifiers.
+func KeyChord(keys ...Key) Key {
+	members := make([]Key, 0, len(keys))
+	for _, k := range keys {
+		if k.kind == keyChord {
+			members = append(members, getCompositeKey(k).keys...)
+			continue
+		}
+		if k.name == "" {
+			panic("unexpected chord key")
+		}
+		if k.mod != 0 {
+			panic("chord keys can't have modifiers: " + k.String())
+		}
+		members = append(members, k)
+	}
+	if len(members) < 2 {
+		panic("a chord requires at least two keys")
+	}
+	// The wrapper key names don't include all of their parameters,
+	// so the members names are not enough to identify the chord.
+	name := ""
+	id := ""
+	for i, k := range members {
+		for _, other := range members[:i] {
+			if other == k {
+				panic("duplicated chord key: " + k.String())
+			}
+		}
+		if i != 0 {
+			name += "+"
+			id += "+"
+		}
+		name += k.String()
+		id += compositeKeyPartID(k)
+	}
+	return registerCompositeKey(keyChord, name, id, compositeKey{keys: members})
+}
+
+// SequenceStep is a single KeySequenceSteps element.
+type SequenceStep struct {
+	// Key is a key that should be pressed during this step.
+	Key Key
+
+	// Window is the max number of ticks (System.Update calls)
+	// that can pass between the previous step and this step.
+	// If the step is not performed in time, the sequence is reset.
+	//
+	// A zero value means there is no time limit.
+	// The window of the first step is ignored.
+	Window int
+}
+
+// KeySequence creates a key that is activated when the given keys
+// are pressed one after another, like up,up,down,down,left,right,b,a.
+// Every step should be performed within the window ticks after the previous one.
+// Only the sequence own keys can reset its progress, see KeySequenceSteps.
+//
+// It's a shorthand for KeySequenceSteps with the same window for all steps.
+func KeySequence(window int, keys ...Key) Key {
+	steps := make([]SequenceStep, len(keys))
+	for i, k := range keys {
+		steps[i] = SequenceStep{Key: k, Window: window}
+	}
+	return KeySequenceSteps(steps...)
+}
+
+// KeySequenceSteps creates a key that is activated when the steps
+// are performed in the given order, each step within its time window.
+//
+// The sequence key is "just pressed" (and "pressed") only during the
+// frame when its last step was performed, it has no "just released" state.
+// Pressing any of the sequence keys out of order resets the sequence progress,
+// while the keys that are not a part of the sequence are ignored.
+// For example, up,x,down is performed by the up,y,x,down inputs,
+// but not by up,x,x,down. The unrelated presses only consume the step time windows.
+//
+// Any keys can be used as steps, including chords and keys with modifiers.
+// A step key is performed when it's just pressed; this includes the simulated key events.
+//
+// The sequence name is its key names joined by ",", like "gamepad_x,gamepad_x,gamepad_y".
+// Sequences can't be parsed by ParseKey.
+//
+// The sequence keys are tracked by the handler during every System.Update,
+// even if their actions are not queried during that frame.
+// If a handler keymap is modified, use Handler.Remap to apply the changes.
+//
+// It panics if there are less than two steps or some of the windows are negative.
+func KeySequenceSteps(steps ...SequenceStep) Key {
+	if len(steps) < 2 {
+		panic("a sequence requires at least two steps")
+	}
+	data := compositeKey{
+		keys:    make([]Key, len(steps)),
+		windows: make([]int, len(steps)),
+	}
+	name := ""
+	id := ""
+	for i, step := range steps {
+		if step.Key.name == "" {
+			panic("unexpected sequence key")
+		}
+		if step.Window < 0 {
+			panic("negative sequence step window")
+		}
+		data.keys[i] = step.Key
+		data.windows[i] = step.Window
+		if i != 0 {
+			name += ","
+			id += ","
+		}
+		name += step.Key.String()
+		id += compositeKeyPartID(step.Key) + "/" + strconv.Itoa(step.Window)
+	}
+	return registerCompositeKey(keySequence, name, id, data)
+}
+
+// KeyMultiTap creates a key that is activated when k is tapped n times in a row,
+// like a double-tap of w or a double-click of the left mouse button.
+//
+// Every next tap should happen within the interval seconds after the previous one,
+// otherwise the taps counter is reset.
+// A zero interval means the GestureConfig.MultiTapInterval value (0.3 seconds by default).
+// Unlike an explicit interval, it follows the System.SetGestureConfig changes.
+// The time is measured using the System.UpdateWithDelta time delta.
+//
+// A tap is registered when k becomes "just pressed" (this includes the simulated key events),
+// so a mouse button double-click is activated by the second button press.
+// The multi-tap key is "just pressed" (and "pressed") during the frame of the final tap
+// and its EventInfo.Pos is the final tap position for the keys that have a position.
+// Note that k keeps activating its own actions during every tap.
+//
+// The multi-tap name is the key name followed by "*n", like "w*2".
+// Multi-tap keys can't be parsed by ParseKey.
+//
+// Like sequences, multi-tap keys are tracked by the handler during every System.Update.
+//
+// It panics if n is less than 2 or the interval is negative.
+func KeyMultiTap(k Key, n int, interval float64) Key {
+	if n < 2 {
+		panic("a multi-tap key requires at least two taps")
+	}
+	if interval < 0 {
+		panic("negative multi-tap interval")
+	}
+	if k.name == "" {
+		panic("unexpected multi-tap key")
+	}
+	name := k.String() + "*" + strconv.Itoa(n)
+	id := compositeKeyPartID(k) + "*" + strconv.Itoa(n) + "/" + strconv.FormatFloat(interval, 'g', -1, 64)
+	data := compositeKey{
+		keys:     []Key{k},
+		taps:     n,
+		interval: interval,
+	}
+	return registerCompositeKey(keyMultiTap, name, id, data)
+}
+
+// KeyHold creates a key that is activated when k is being held
+// for at least the given duration, in seconds.
+// The time is measured using the System.UpdateWithDelta time delta,
+// so it works for any key type, not only for the keyboard keys.
+//
+// The hold key is "just pressed" once, when the duration threshold is reached.
+// Then it stays "pressed" until k is released; that frame is reported as "just released".
+// Its EventInfo.Pos is the current k position for the keys that have a position.
+//
+// Use Handler.ActionHoldProgress to get the hold progress,
+// it's useful for the UI elements like a filling ring.
+//
+// The hold key name is "hold(k)", like "hold(e)".
+//
+// Like sequences, hold keys are tracked by the handler during every System.Update.
+//
+// It panics if the duration is not positive.
+func KeyHold(k Key, duration float64) Key {
+	return newHoldKey(keyHold, "hold", k, duration)
+}
+
+// KeyTap is a KeyHold counterpart: it's activated when k is released
+// before the given duration, in seconds, has passed since k was pressed.
+//
+// Binding KeyTap(k, d) and KeyHold(k, d) to different actions makes
+// it possible to distinguish between a tap and a hold of the same key.
+//
+// The tap key is "just pressed" (and "pressed") only during the frame when k is released.
+// Its EventInfo.Pos is the last k position for the keys that have a position.
+//
+// The tap key name is "tap(k)", like "tap(e)".
+//
+// It panics if the duration is not positive.
+func KeyTap(k Key, duration float64) Key {
+	return newHoldKey(keyTap, "tap", k, duration)
+}
+
+func newHoldKey(kind keyKind, prefix string, k Key, duration float64) Key {
+	if duration <= 0 {
+		panic("non-positive " + prefix + " key duration")
+	}
+	if k.name == "" {
+		panic("unexpected " + prefix + " key")
+	}
+	name := prefix + "(" + k.String() + ")"
+	id := compositeKeyPartID(k) + "/" + strconv.FormatFloat(duration, 'g', -1, 64)
+	data := compositeKey{
+		keys:     []Key{k},
+		duration: duration,
+	}
+	return registerCompositeKey(kind, name, id, data)
+}
+
+// KeyRepeat creates a key that auto-repeats while k is being held,
+// like a text cursor or a menu selection.
+//
+// The repeat key is "just pressed" when k is pressed.
+// If k is still held after the delay, in seconds, the key is "just pressed" again;
+// after that, it's "just pressed" every interval seconds until k is released.
+// The time is measured using the System.UpdateWithDelta time delta.
+//
+// It stays "pressed" while k is held; the release frame is reported as "just released".
+// Its EventInfo.Pos is the current k position for the keys that have a position.
+//
+// Any key can be repeated, including the stick direction keys
+// like KeyGamepadLStickDown:
+//
+//	ActionMenuDown: {input.KeyRepeat(input.KeyGamepadLStickDown, 0.4, 0.08)},
+//
+// The repeat key name is "repeat(k)", like "repeat(down)".
+//
+// It panics if the delay or the interval is not positive.
+func KeyRepeat(k Key, delay, interval float64) Key {
+	if delay <= 0 || interval <= 0 {
+		panic("non-positive repeat key delay or interval")
+	}
+	if k.name == "" {
+		panic("unexpected repeat key")
+	}
+	name := "repeat(" + k.String() + ")"
+	id := compositeKeyPartID(k) + "/" + strconv.FormatFloat(delay, 'g', -1, 64) + "/" + strconv.FormatFloat(interval, 'g', -1, 64)
+	data := compositeKey{
+		keys:     []Key{k},
+		duration: delay,
+		interval: interval,
+	}
+	return registerCompositeKey(keyRepeat, name, id, data)
+}
+
+// KeyAxis creates a one-dimensional axis key from two opposing keys,
+// like KeyAxis(KeyLeft, KeyRight).
+//
+// The axis value is in [-1, 1] range: the negative key contributes
+// a negative value and the positive key contributes a positive value.
+// The analog keys, like triggers or stick directions, contribute their ActionValue.
+// Use ActionValue or ActionVector (X component) to get the axis value.
+// The axis value is also available as EventInfo.Pos.X.
+//
+// The axis is "pressed" while its value is not zero.
+// When both keys are pressed, they cancel each other out,
+// unless the handler AxisLastPressedWins option is enabled.
+//
+// The axis key name is "axis(negative,positive)", like "axis(left,right)".
+// Axis keys can't be parsed by ParseKey.
+//
+// It panics if both keys are the same.
+func KeyAxis(negative, positive Key) Key {
+	return newAxisKey(keyAxis, "axis", negative, positive)
+}
+
+// KeyVector2 creates a two-dimensional vector key from four direction keys,
+// like KeyVector2(KeyW, KeyS, KeyA, KeyD).
+//
+// The vector is built from two axes: KeyAxis(left, right) for X
+// and KeyAxis(up, down) for Y (the Y axis points down, like the screen coordinates).
+// Use ActionVector to get the vector value, it's also available as EventInfo.Pos.
+//
+// By default, the vector length is clamped to 1, so the diagonal movement
+// is not faster than the straight one; see the handler AxisRawDiagonals option.
+// To combine the digital keys with a stick, bind them to the same action:
+//
+//	ActionMove: {input.KeyVector2(input.KeyW, input.KeyS, input.KeyA, input.KeyD), input.KeyGamepadLStickMotion}
+//
+// The vector key name is "vector(up,down,left,right)", like "vector(w,s,a,d)".
+// Vector keys can't be parsed by ParseKey.
+//
+// It panics if any of the keys are the same.
+func KeyVector2(up, down, left, right Key) Key {
+	return newAxisKey(keyVector2, "vector", up, down, left, right)
+}
+
+func newAxisKey(kind keyKind, prefix string, keys ...Key) Key {
+	name := prefix + "("
+	id := ""
+	for i, k := range keys {
+		if k.name == "" {
+			panic("unexpected " + prefix + " key")
+		}
+		if keySliceContains(keys[:i], k) {
+			panic("duplicated " + prefix + " key: " + k.String())
+		}
+		if i != 0 {
+			name += ","
+			id += ","
+		}
+		name += k.String()
+		id += compositeKeyPartID(k)
+	}
+	name += ")"
+	data := compositeKey{keys: keys}
+	return registerCompositeKey(kind, name, id, data)
+}
+
+// KeyTriggerThreshold creates a trigger key with its own activation threshold.
+// The threshold is a trigger value in (0, 1] range.
+//
+// This makes it possible to bind a half-pull and a full-pull of the same trigger
+// to different actions:
+//
+//	ActionAim:  {input.KeyTriggerThreshold(input.KeyGamepadLTrigger, 0.3)},
+//	ActionZoom: {input.KeyTriggerThreshold(input.KeyGamepadLTrigger, 0.95)},
+//
+// The handler TriggerHysteresis is still used for the release.
+//
+// The key name is the trigger key name followed by "@threshold", like "gamepad_ltrigger@0.3".
+// These keys can't be parsed by ParseKey.
+//
+// It panics if k is not a trigger key or the threshold is out of range.
+func KeyTriggerThreshold(k Key, threshold float64) Key {
+	if k.kind != keyGamepadTrigger {
+		panic("unexpected trigger key")
+	}
+	if threshold <= 0 || threshold > 1 {
+		panic("trigger threshold is out of range")
+	}
+	thresholdString := strconv.FormatFloat(threshold, 'g', -1, 64)
+	data := compositeKey{
+		keys:      []Key{k},
+		threshold: threshold,
+	}
+	return registerCompositeKey(keyTriggerThreshold, k.name+"@"+thresholdString, "", data)
+}
+
 // Wheel keys.
 //
 // Wheel keys do not have constantly pressed state,
@@ -104,6 +462,16 @@ var (
 
 	// KeyWheelVertical handles both up and down movements.
 	KeyWheelVertical = Key{code: int(wheelVertical), kind: keyWheel, name: "wheel_vertical"}
+
+	// KeyWheelLeft handles only scroll-left movement.
+	// The horizontal scrolling is usually performed by a trackpad or a tilt wheel.
+	KeyWheelLeft = Key{code: int(wheelLeft), kind: keyWheel, name: "wheel_left"}
+
+	// KeyWheelRight handles only scroll-right movement.
+	KeyWheelRight = Key{code: int(wheelRight), kind: keyWheel, name: "wheel_right"}
+
+	// KeyWheelHorizontal handles both left and right movements.
+	KeyWheelHorizontal = Key{code: int(wheelHorizontal), kind: keyWheel, name: "wheel_horizontal"}
 )
 
 // Mouse keys.
@@ -117,6 +485,17 @@ var (
 	// A special event that is triggered if the left mouse button is being pressed
 	// and the cursor is moved. This is useful for UI interfaces to detect drag-and-drop triggers.
 	KeyMouseLeftDrag = Key{code: int(ebiten.MouseButtonLeft), kind: keyMouseDrag, name: "mouse_left_drag"}
+
+	// Like KeyMouseLeftDrag, but for the right and middle mouse buttons.
+	// Useful for things like RTS camera panning and box selection.
+	KeyMouseRightDrag  = Key{code: int(ebiten.MouseButtonRight), kind: keyMouseDrag, name: "mouse_right_drag"}
+	KeyMouseMiddleDrag = Key{code: int(ebiten.MouseButtonMiddle), kind: keyMouseDrag, name: "mouse_middle_drag"}
+
+	// Left mouse button drag swipes, see KeyTouchSwipeUp for the details.
+	KeyMouseSwipeUp    = Key{code: int(swipeUp), kind: keyMouseSwipe, name: "mouse_swipe_up"}
+	KeyMouseSwipeDown  = Key{code: int(swipeDown), kind: keyMouseSwipe, name: "mouse_swipe_down"}
+	KeyMouseSwipeLeft  = Key{code: int(swipeLeft), kind: keyMouseSwipe, name: "mouse_swipe_left"}
+	KeyMouseSwipeRight = Key{code: int(swipeRight), kind: keyMouseSwipe, name: "mouse_swipe_right"}
 )
 
 // Touch keys.
@@ -124,10 +503,30 @@ var (
 var (
 	KeyTouchTap = Key{code: int(touchTap), kind: keyTouch, name: "touch_tap"}
 
-	// Like a tap, but user was holding that gesture for at least 0.5s.
+	// Like a tap, but user was holding that gesture for at least
+	// GestureConfig.LongTapDuration seconds (0.5s by default).
 	KeyTouchLongTap = Key{code: int(touchLongTap), kind: keyTouch, name: "touch_long_tap"}
 
 	KeyTouchDrag = Key{kind: keyTouchDrag, name: "touch_drag"}
+
+	// Swipe keys are triggered when a fast and mostly straight drag is released.
+	// They're only pressed during that release frame.
+	// The event info carries the swipe start and end positions (StartPos and Pos)
+	// and the Velocity.
+	KeyTouchSwipeUp    = Key{code: int(swipeUp), kind: keyTouchSwipe, name: "touch_swipe_up"}
+	KeyTouchSwipeDown  = Key{code: int(swipeDown), kind: keyTouchSwipe, name: "touch_swipe_down"}
+	KeyTouchSwipeLeft  = Key{code: int(swipeLeft), kind: keyTouchSwipe, name: "touch_swipe_left"}
+	KeyTouchSwipeRight = Key{code: int(swipeRight), kind: keyTouchSwipe, name: "touch_swipe_right"}
+
+	// A two-finger gesture where the touches move apart or together.
+	// The event info carries the gesture center (Pos and StartPos) and the ScaleDelta.
+	// The key stays pressed until one of the gesture touches is released.
+	KeyTouchPinch = Key{code: int(touchPinch), kind: keyTouch, name: "touch_pinch"}
+
+	// A two-finger gesture where the touches twist around their center.
+	// The event info carries the gesture center (Pos and StartPos) and the RotationDelta.
+	// The key stays pressed until one of the gesture touches is released.
+	KeyTouchRotate = Key{code: int(touchRotate), kind: keyTouch, name: "touch_rotate"}
 )
 
 // Keyboard keys.
@@ -193,6 +592,9 @@ var (
 	KeyControl      = Key{code: int(ebiten.KeyControl), name: "control"}
 	KeyControlLeft  = Key{code: int(ebiten.KeyControlLeft), name: "control_left"}
 	KeyControlRight = Key{code: int(ebiten.KeyControlRight), name: "control_right"}
+	KeyMeta         = Key{code: int(ebiten.KeyMeta), name: "meta"}
+	KeyMetaLeft     = Key{code: int(ebiten.KeyMetaLeft), name: "meta_left"}
+	KeyMetaRight    = Key{code: int(ebiten.KeyMetaRight), name: "meta_right"}
 	KeyDelete       = Key{code: int(ebiten.KeyDelete), name: "delete"}
 	KeyEnd          = Key{code: int(ebiten.KeyEnd), name: "end"}
 	KeyEnter        = Key{code: int(ebiten.KeyEnter), name: "enter"}
@@ -284,4 +686,12 @@ var (
 	KeyGamepadL2 = Key{code: int(ebiten.StandardGamepadButtonFrontBottomLeft), kind: keyGamepad, name: "gamepad_l2"}
 	KeyGamepadR1 = Key{code: int(ebiten.StandardGamepadButtonFrontTopRight), kind: keyGamepad, name: "gamepad_r1"}
 	KeyGamepadR2 = Key{code: int(ebiten.StandardGamepadButtonFrontBottomRight), kind: keyGamepad, name: "gamepad_r2"}
+
+	// Analog trigger keys.
+	// Unlike KeyGamepadL2 and KeyGamepadR2, they're activated when the trigger value
+	// reaches the handler TriggerThreshold and released when it goes below the
+	// threshold minus TriggerHysteresis. Use KeyTriggerThreshold for a per-key threshold.
+	// The trigger value is available as EventInfo.Value.
+	KeyGamepadLTrigger = Key{code: int(ebiten.StandardGamepadButtonFrontBottomLeft), kind: keyGamepadTrigger, name: "gamepad_ltrigger"}
+	KeyGamepadRTrigger = Key{code: int(ebiten.StandardGamepadButtonFrontBottomRight), kind: keyGamepadTrigger, name: "gamepad_rtrigger"}
 )
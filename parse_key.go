@@ -18,6 +18,17 @@ import (
 //   - keyname
 //   - mod+keyname
 //   - mod+mod+keyname
+//   - keyname+keyname
+//
+// Any combination of the modifiers is allowed, but every
+// modifier can be specified only once.
+// The supported modifiers are "ctrl", "shift", "alt" and "meta".
+// The "cmd" modifier is an alias for "meta".
+//
+// If the modifiers are followed by a key that doesn't support them
+// or there are several non-modifier keys, the result is a chord key
+// (see KeyChord). Every chord part can be either a key name or a modifier name,
+// so "ctrl+gamepad_a" is a chord of KeyControl and KeyGamepadA.
 //
 // Some valid input examples:
 //
@@ -26,6 +37,10 @@ import (
 //   - "ctrl+left"
 //   - "ctrl+shift+left"
 //   - "shift+ctrl+left"
+//   - "alt+mouse_left_button"
+//   - "cmd+s"
+//   - "q+e"
+//   - "gamepad_l1+gamepad_a"
 //
 // See Handler.ActionKeyNames() for more information about the key names.
 func ParseKey(s string) (Key, error) {
@@ -39,15 +54,68 @@ func ParseKey(s string) (Key, error) {
 	}
 	modName := s[:plusPos]
 	keyName := s[plusPos+1:]
-	mod := keyModifierByName(modName)
-	if mod == ModUnknown {
-		return Key{}, errors.New("unknown key modifier: " + modName)
+	if !isKeyModifiersList(modName) {
+		return parseKeyChord(s)
+	}
+	mod, err := parseKeyModifiers(modName)
+	if err != nil {
+		return Key{}, err
 	}
 	k := keyByName(keyName)
 	if (k == Key{}) {
 		return k, errors.New("unknown key: " + keyName)
 	}
-	return KeyWithModifier(k, mod), nil
+	switch k.kind {
+	case keyKeyboard, keyMouse, keyWheel:
+		return KeyWithModifier(k, mod), nil
+	default:
+		return parseKeyChord(s)
+	}
+}
+
+func parseKeyChord(s string) (Key, error) {
+	parts := strings.Split(s, "+")
+	keys := make([]Key, 0, len(parts))
+	for _, name := range parts {
+		k := keyByName(name)
+		if (k == Key{}) {
+			k = keyModifierKey(keyModifierByName(name))
+		}
+		if (k == Key{}) {
+			return Key{}, errors.New("unknown key: " + name)
+		}
+		for _, other := range keys {
+			if other == k {
+				return Key{}, errors.New("duplicated chord key: " + name)
+			}
+		}
+		keys = append(keys, k)
+	}
+	return KeyChord(keys...), nil
+}
+
+func isKeyModifiersList(s string) bool {
+	for _, modName := range strings.Split(s, "+") {
+		if keyModifierByName(modName) == ModUnknown {
+			return false
+		}
+	}
+	return true
+}
+
+func parseKeyModifiers(s string) (KeyModifier, error) {
+	var mod KeyModifier
+	for _, modName := range strings.Split(s, "+") {
+		m := keyModifierByName(modName)
+		if m == ModUnknown {
+			return ModUnknown, errors.New("unknown key modifier: " + modName)
+		}
+		if mod&m != 0 {
+			return ModUnknown, errors.New("duplicated key modifier: " + modName)
+		}
+		mod |= m
+	}
+	return mod, nil
 }
 
 func keyModifierByName(name string) KeyModifier {
@@ -56,13 +124,30 @@ func keyModifierByName(name string) KeyModifier {
 		return ModControl
 	case "shift":
 		return ModShift
-	case "ctrl+shift", "shift+ctrl":
-		return ModControlShift
+	case "alt":
+		return ModAlt
+	case "meta", "cmd":
+		return ModMeta
 	default:
 		return ModUnknown
 	}
 }
 
+func keyModifierKey(mod KeyModifier) Key {
+	switch mod {
+	case ModControl:
+		return KeyControl
+	case ModShift:
+		return KeyShift
+	case ModAlt:
+		return KeyAlt
+	case ModMeta:
+		return KeyMeta
+	default:
+		return Key{}
+	}
+}
+
 func keyByName(name string) Key {
 	// Keys are sorted by a name, so we can use a binary search here.
 	i := sort.Search(len(allKeys), func(i int) bool {
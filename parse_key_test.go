@@ -0,0 +1,113 @@
+package input
+
+import (
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Key
+		name string
+	}{
+		{"left", KeyLeft, "left"},
+		{"gamepad_left", KeyGamepadLeft, "gamepad_left"},
+		{"ctrl+left", KeyWithModifier(KeyLeft, ModControl), "ctrl+left"},
+		{"ctrl+shift+left", KeyWithModifier(KeyLeft, ModControlShift), "ctrl+shift+left"},
+		{"shift+ctrl+left", KeyWithModifier(KeyLeft, ModControlShift), "ctrl+shift+left"},
+		{"alt+f4", KeyWithModifier(KeyF4, ModAlt), "alt+f4"},
+		{"meta+s", KeyWithModifier(KeyS, ModMeta), "meta+s"},
+		{"cmd+s", KeyWithModifier(KeyS, ModMeta), "meta+s"},
+		{"alt+mouse_left_button", KeyWithModifier(KeyMouseLeft, ModAlt), "alt+mouse_left_button"},
+		{"meta+alt+wheel_up", KeyWithModifier(KeyWheelUp, ModAlt|ModMeta), "alt+meta+wheel_up"},
+		{"wheel_left", KeyWheelLeft, "wheel_left"},
+		{"shift+wheel_horizontal", KeyWithModifier(KeyWheelHorizontal, ModShift), "shift+wheel_horizontal"},
+		{"cmd+alt+shift+ctrl+z", KeyWithModifier(KeyZ, ModControl|ModShift|ModAlt|ModMeta), "ctrl+shift+alt+meta+z"},
+		{"q+e", KeyChord(KeyQ, KeyE), "q+e"},
+		{"gamepad_l1+gamepad_a", KeyChord(KeyGamepadL1, KeyGamepadA), "gamepad_l1+gamepad_a"},
+		{"shift+mouse_right_button", KeyWithModifier(KeyMouseRight, ModShift), "shift+mouse_right_button"},
+		{"shift+mouse_right_button+mouse_left_button", KeyChord(KeyShift, KeyMouseRight, KeyMouseLeft), "shift+mouse_right_button+mouse_left_button"},
+		{"ctrl+gamepad_a", KeyChord(KeyControl, KeyGamepadA), "control+gamepad_a"},
+		{"control+gamepad_a", KeyChord(KeyControl, KeyGamepadA), "control+gamepad_a"},
+		{"ctrl+q+e", KeyChord(KeyControl, KeyQ, KeyE), "control+q+e"},
+		{"touch_tap+space", KeyChord(KeyTouchTap, KeySpace), "touch_tap+space"},
+	}
+
+	for _, test := range tests {
+		have, err := ParseKey(test.s)
+		if err != nil {
+			t.Fatalf("ParseKey(%q): unexpected error: %v", test.s, err)
+		}
+		if have != test.want {
+			t.Fatalf("ParseKey(%q):\nhave: %#v\nwant: %#v", test.s, have, test.want)
+		}
+		if have.String() != test.name {
+			t.Fatalf("ParseKey(%q).String():\nhave: %q\nwant: %q", test.s, have.String(), test.name)
+		}
+	}
+}
+
+func TestParseKeyError(t *testing.T) {
+	tests := []struct {
+		s   string
+		err string
+	}{
+		{"", "unknown key: "},
+		{"foo", "unknown key: foo"},
+		{"ctrl+foo", "unknown key: foo"},
+		{"hyper+a", "unknown key: hyper"},
+		{"ctrl+ctrl+a", "duplicated key modifier: ctrl"},
+		{"meta+cmd+a", "duplicated key modifier: cmd"},
+		{"q+", "unknown key: "},
+		{"q+q", "duplicated chord key: q"},
+		{"ctrl+control+gamepad_a", "duplicated chord key: control"},
+		{"gamepad_a+foo+gamepad_b", "unknown key: foo"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseKey(test.s)
+		if err == nil {
+			t.Fatalf("ParseKey(%q): expected an error", test.s)
+		}
+		if err.Error() != test.err {
+			t.Fatalf("ParseKey(%q) error:\nhave: %q\nwant: %q", test.s, err.Error(), test.err)
+		}
+	}
+}
+
+func TestKeyChord(t *testing.T) {
+	if KeyChord(KeyQ, KeyE) != KeyChord(KeyQ, KeyE) {
+		t.Fatal("identical chords are not equal")
+	}
+	if KeyChord(KeyQ, KeyE) == KeyChord(KeyE, KeyQ) {
+		t.Fatal("chords with different keys order are equal")
+	}
+	nested := KeyChord(KeyChord(KeyGamepadL1, KeyGamepadR1), KeyGamepadA)
+	if nested != KeyChord(KeyGamepadL1, KeyGamepadR1, KeyGamepadA) {
+		t.Fatal("nested chord is not flattened")
+	}
+	if nested.String() != "gamepad_l1+gamepad_r1+gamepad_a" {
+		t.Fatalf("unexpected nested chord name: %q", nested.String())
+	}
+	if KeyChord(KeyHold(KeyE, 1), KeyQ) == KeyChord(KeyHold(KeyE, 2), KeyQ) {
+		t.Fatal("chords with different hold durations are equal")
+	}
+	if data := getCompositeKey(KeyChord(KeyHold(KeyE, 2), KeyQ)); data.keys[0] != KeyHold(KeyE, 2) {
+		t.Fatal("chord uses the members of another chord with the same name")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("a chord with a modified key is accepted")
+			}
+		}()
+		KeyChord(KeyWithModifier(KeyS, ModControl), KeyMouseLeft)
+	}()
+	chord := KeyChord(KeyShift, KeyMouseRight)
+	if keyDevice(chord) != KeyboardDevice|MouseDevice {
+		t.Fatalf("unexpected chord device: %s", keyDevice(chord))
+	}
+	if !keyHasPos(chord) || keyHasDuration(chord) {
+		t.Fatal("unexpected chord flags")
+	}
+}
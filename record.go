@@ -0,0 +1,203 @@
+package input
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Recorder is a Backend that captures the input devices state.
+//
+// Every frame, it reads the complete devices state from the
+// wrapped backend and writes it to the output stream.
+// The recorded stream can be played back using Player.
+//
+// The recorder answers all queries using the captured frames,
+// so the recorded session and its replay produce the same results.
+//
+// Use it as a System backend:
+//
+//	rec := input.NewRecorder(f, input.EbitenBackend{})
+//	inputSystem.Init(input.SystemConfig{
+//		DevicesEnabled: input.AnyDevice,
+//		Backend:        rec,
+//	})
+//
+// The time deltas passed to the System.UpdateWithDelta are recorded too,
+// the player feeds them back to the system during the playback.
+// The simulated events are not a part of the recording,
+// the game should reproduce them on its own.
+//
+// Use NewRecorder to create a usable object of this type.
+//
+// Experimental: this is a part of the backend API, which is not stable yet.
+type Recorder struct {
+	frameBackend
+
+	backend Backend
+	w       io.Writer
+	frame   recordedFrame
+	buf     []byte
+	err     error
+}
+
+var _ Backend = (*Recorder)(nil)
+var _ AnalogButtonBackend = (*Recorder)(nil)
+var _ FrameDeltaBackend = (*Recorder)(nil)
+
+// NewRecorder creates a recorder that reads the devices state
+// from the given backend and writes it to w.
+// If backend is nil, EbitenBackend is used.
+//
+// The recording header is written immediately.
+// The writes are unbuffered: a recorder performs one write per frame.
+func NewRecorder(w io.Writer, backend Backend) *Recorder {
+	if backend == nil {
+		backend = EbitenBackend{}
+	}
+	r := &Recorder{
+		backend: backend,
+		w:       w,
+		buf:     make([]byte, 0, 64),
+	}
+	r.buf = append(r.buf, recordMagic...)
+	r.buf = binary.LittleEndian.AppendUint16(r.buf, recordVersion)
+	_, r.err = w.Write(r.buf)
+	return r
+}
+
+// Err returns the first write error that occurred during the recording.
+// After the error, the recorder keeps working, but it stops writing the frames.
+func (r *Recorder) Err() error { return r.err }
+
+// Update implements Backend interface.
+// The recorded time delta is 1/60, like in System.Update.
+func (r *Recorder) Update() {
+	r.UpdateWithDelta(1.0 / 60.0)
+}
+
+// UpdateWithDelta implements FrameDeltaBackend interface.
+// It records the frame with the given time delta and returns it.
+func (r *Recorder) UpdateWithDelta(delta float64) float64 {
+	delta = updateBackend(r.backend, delta)
+	r.frame.capture(r.backend)
+	r.frame.delta = delta
+	r.pushFrame(&r.frame)
+
+	if r.err != nil {
+		return delta
+	}
+	buf := append(r.buf[:0], 0, 0, 0, 0)
+	buf = r.frame.encode(buf)
+	binary.LittleEndian.PutUint32(buf, uint32(len(buf)-4))
+	_, r.err = r.w.Write(buf)
+	r.buf = buf
+	return delta
+}
+
+// Player is a Backend that plays back the input recorded by Recorder.
+//
+// Every Update call consumes one recorded frame.
+// When there are no more frames left, the player reports
+// no keys pressed and Finished starts to return true.
+//
+// When used as a System backend, the player also replays
+// the recorded time deltas, see FrameDeltaBackend.
+//
+// Use NewPlayer to create a usable object of this type.
+//
+// Experimental: this is a part of the backend API, which is not stable yet.
+type Player struct {
+	frameBackend
+
+	r        *bufio.Reader
+	frame    recordedFrame
+	buf      []byte
+	finished bool
+	err      error
+}
+
+var _ Backend = (*Player)(nil)
+var _ AnalogButtonBackend = (*Player)(nil)
+var _ FrameDeltaBackend = (*Player)(nil)
+
+// NewPlayer creates a player that reads the recording from r.
+//
+// It returns an error if the recording header is malformed
+// or the recording version is not supported.
+func NewPlayer(r io.Reader) (*Player, error) {
+	br := bufio.NewReader(r)
+	var header [len(recordMagic) + 2]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, fmt.Errorf("read recording header: %w", err)
+	}
+	if string(header[:len(recordMagic)]) != recordMagic {
+		return nil, errors.New("not an input recording")
+	}
+	version := binary.LittleEndian.Uint16(header[len(recordMagic):])
+	if version != recordVersion {
+		return nil, fmt.Errorf("unsupported input recording version %d", version)
+	}
+	p := &Player{
+		r:   br,
+		buf: make([]byte, 0, 64),
+	}
+	return p, nil
+}
+
+// Finished reports whether all recorded frames were played.
+func (p *Player) Finished() bool { return p.finished }
+
+// Err returns an error that stopped the playback, if any.
+// A recording that ends properly is not considered to be an error.
+func (p *Player) Err() error { return p.err }
+
+// Update implements Backend interface.
+func (p *Player) Update() {
+	p.UpdateWithDelta(0)
+}
+
+// UpdateWithDelta implements FrameDeltaBackend interface.
+// It returns the recorded frame time delta.
+// After the playback is finished, the given delta is returned as is.
+func (p *Player) UpdateWithDelta(delta float64) float64 {
+	if !p.finished {
+		if err := p.readFrame(); err != nil {
+			if err != io.EOF {
+				p.err = err
+			}
+			p.finished = true
+		}
+	}
+	if p.finished {
+		p.frame.reset()
+		p.pushFrame(&p.frame)
+		return delta
+	}
+	p.pushFrame(&p.frame)
+	return p.frame.delta
+}
+
+func (p *Player) readFrame() error {
+	var size [4]byte
+	if _, err := io.ReadFull(p.r, size[:]); err != nil {
+		return err
+	}
+	n := int(binary.LittleEndian.Uint32(size[:]))
+	if n > maxRecordFrameSize {
+		return errBadRecordFrame
+	}
+	if cap(p.buf) < n {
+		p.buf = make([]byte, n)
+	}
+	p.buf = p.buf[:n]
+	if _, err := io.ReadFull(p.r, p.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return p.frame.decode(p.buf)
+}
@@ -0,0 +1,172 @@
+package input_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	input "github.com/quasilyte/ebitengine-input"
+	"github.com/quasilyte/ebitengine-input/inputtest"
+)
+
+func TestRecordReplay(t *testing.T) {
+	const (
+		actionJump input.Action = iota + 1
+		actionClick
+		actionDrag
+		actionTap
+		actionMove
+		actionScroll
+		actionThrottle
+		actionCharge
+	)
+	keymap := input.Keymap{
+		actionJump:     {input.KeySpace, input.KeyGamepadA},
+		actionClick:    {input.KeyWithModifier(input.KeyMouseLeft, input.ModControl)},
+		actionDrag:     {input.KeyMouseLeftDrag},
+		actionTap:      {input.KeyTouchTap},
+		actionMove:     {input.KeyGamepadLStickMotion},
+		actionScroll:   {input.KeyWheelVertical},
+		actionThrottle: {input.KeyGamepadR2},
+		actionCharge:   {input.KeyHold(input.KeySpace, 0.1)},
+	}
+	actions := []input.Action{actionJump, actionClick, actionDrag, actionTap, actionMove, actionScroll, actionThrottle, actionCharge}
+
+	// The hold timing depends on the frame time deltas,
+	// the replay uses the recorded deltas.
+	frameDelta := func(i int) float64 {
+		if i%2 == 0 {
+			return 0.1
+		}
+		return 1.0 / 60.0
+	}
+
+	b := inputtest.NewBackend()
+	script := []func(){
+		func() { b.ConnectGamepad(0, "test gamepad") },
+		func() { b.PressKey(ebiten.KeySpace) },
+		func() {},
+		func() { b.ReleaseKey(ebiten.KeySpace) },
+		func() {
+			b.PressKey(ebiten.KeyControlLeft)
+			b.MoveCursor(10, 10)
+			b.PressMouseButton(ebiten.MouseButtonLeft)
+		},
+		func() { b.MoveCursor(40, 10) },
+		func() {
+			b.ReleaseKey(ebiten.KeyControlLeft)
+			b.ReleaseMouseButton(ebiten.MouseButtonLeft)
+		},
+		func() { b.PressTouch(3, 100, 200) },
+		func() { b.ReleaseTouch(3) },
+		func() { b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickHorizontal, 0.75) },
+		func() { b.PressGamepadButton(0, ebiten.StandardGamepadButtonRightBottom) },
+		func() { b.SetGamepadAxis(0, ebiten.StandardGamepadAxisLeftStickHorizontal, 0) },
+		func() { b.ScrollWheel(0, 1.5) },
+		func() { b.ReleaseGamepadButton(0, ebiten.StandardGamepadButtonRightBottom) },
+		func() { b.SetGamepadButtonValue(0, ebiten.StandardGamepadButtonFrontBottomRight, 0.25) },
+		func() { b.SetGamepadButtonValue(0, ebiten.StandardGamepadButtonFrontBottomRight, 0.75) },
+	}
+
+	describeFrame := func(h *input.Handler) string {
+		var parts []string
+		for _, a := range actions {
+			if info, ok := h.JustPressedActionInfo(a); ok {
+				parts = append(parts, fmt.Sprintf("%d:just_pressed%v", a, info.Pos))
+			}
+			if info, ok := h.PressedActionInfo(a); ok {
+				parts = append(parts, fmt.Sprintf("%d:pressed%v/%d", a, info.Pos, info.Duration))
+			}
+			if h.ActionIsJustReleased(a) {
+				parts = append(parts, fmt.Sprintf("%d:just_released", a))
+			}
+			if v := h.ActionValue(a); v != 0 {
+				parts = append(parts, fmt.Sprintf("%d:value%v", a, v))
+			}
+		}
+		return strings.Join(parts, " ")
+	}
+
+	var recording bytes.Buffer
+	var recordedLog []string
+	{
+		rec := input.NewRecorder(&recording, b)
+		var sys input.System
+		sys.Init(input.SystemConfig{DevicesEnabled: input.AnyDevice, Backend: rec})
+		h := sys.NewHandler(0, keymap)
+		for i, step := range script {
+			step()
+			sys.UpdateWithDelta(frameDelta(i))
+			recordedLog = append(recordedLog, describeFrame(h))
+		}
+		if err := rec.Err(); err != nil {
+			t.Fatalf("recording error: %v", err)
+		}
+	}
+
+	var replayedLog []string
+	{
+		p, err := input.NewPlayer(&recording)
+		if err != nil {
+			t.Fatalf("create player: %v", err)
+		}
+		var sys input.System
+		sys.Init(input.SystemConfig{DevicesEnabled: input.AnyDevice, Backend: p})
+		h := sys.NewHandler(0, keymap)
+		for range script {
+			sys.Update()
+			replayedLog = append(replayedLog, describeFrame(h))
+		}
+		if p.Finished() {
+			t.Fatal("player finished too early")
+		}
+		sys.Update()
+		if !p.Finished() {
+			t.Fatal("player is not finished after the last frame")
+		}
+		if err := p.Err(); err != nil {
+			t.Fatalf("playback error: %v", err)
+		}
+	}
+
+	for i := range recordedLog {
+		if recordedLog[i] != replayedLog[i] {
+			t.Errorf("frame[%d] mismatch:\nrecorded: %s\nreplayed: %s", i, recordedLog[i], replayedLog[i])
+		}
+	}
+	if !strings.Contains(recordedLog[2], fmt.Sprintf("%d:just_pressed", actionCharge)) {
+		t.Fatalf("the hold key is not activated:\n%s", strings.Join(recordedLog, "\n"))
+	}
+	if recordedLog[1] == "" || recordedLog[5] == "" {
+		t.Fatalf("the recorded session has no events:\n%s", strings.Join(recordedLog, "\n"))
+	}
+}
+
+func TestPlayerBadInput(t *testing.T) {
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{"", "read recording header: EOF"},
+		{"EIR", "read recording header: unexpected EOF"},
+		{"ABCD\x01\x00", "not an input recording"},
+		{"EIRF\x09\x00", "unsupported input recording version 9"},
+	}
+	for _, test := range tests {
+		_, err := input.NewPlayer(strings.NewReader(test.data))
+		if err == nil || err.Error() != test.err {
+			t.Fatalf("NewPlayer(%q): have %v error, want %q", test.data, err, test.err)
+		}
+	}
+
+	p, err := input.NewPlayer(strings.NewReader("EIRF\x01\x00\x03\x00\x00\x00abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Update()
+	if !p.Finished() || p.Err() == nil {
+		t.Fatal("malformed frame is not reported")
+	}
+}
@@ -2,7 +2,6 @@ package input
 
 import (
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 // KeyScanStatus represents the KeyScanner.Scan operation result.
@@ -66,7 +65,7 @@ func (s *KeyScanner) Scan() (Key, KeyScanStatus) {
 
 	// This slice is stack-allocated; for the most cases, 4 keys are enough.
 	keys := make([]ebiten.Key, 0, 4)
-	keys = inpututil.AppendPressedKeys(keys)
+	keys = s.h.sys.backend.AppendPressedKeys(keys)
 
 	if !s.canScan {
 		if len(keys) != 0 {
@@ -121,10 +120,12 @@ func scanKey(keys []ebiten.Key) (Key, bool) {
 	// Remove the modifiers from the slice (inplace).
 	var ctrlKey Key
 	var shiftKey Key
+	var altKey Key
+	var metaKey Key
 	keysWithoutMods := keys[:0]
 	for _, k := range keys {
 		switch k {
-		case ebiten.KeyControl, ebiten.KeyShift:
+		case ebiten.KeyControl, ebiten.KeyShift, ebiten.KeyAlt, ebiten.KeyMeta:
 			// Just omit them from the slice.
 		case ebiten.KeyControlLeft:
 			ctrlKey = KeyControlLeft
@@ -134,12 +135,22 @@ func scanKey(keys []ebiten.Key) (Key, bool) {
 			shiftKey = KeyShiftLeft
 		case ebiten.KeyShiftRight:
 			shiftKey = KeyShiftRight
+		case ebiten.KeyAltLeft:
+			altKey = KeyAltLeft
+		case ebiten.KeyAltRight:
+			altKey = KeyAltRight
+		case ebiten.KeyMetaLeft:
+			metaKey = KeyMetaLeft
+		case ebiten.KeyMetaRight:
+			metaKey = KeyMetaRight
 		default:
 			keysWithoutMods = append(keysWithoutMods, k)
 		}
 	}
 	hasCtrl := ctrlKey.name != ""
 	hasShift := shiftKey.name != ""
+	hasAlt := altKey.name != ""
+	hasMeta := metaKey.name != ""
 
 	var mappedKey Key
 
@@ -165,17 +176,25 @@ Loop:
 			return ctrlKey, true
 		case hasShift:
 			return shiftKey, true
+		case hasAlt:
+			return altKey, true
+		case hasMeta:
+			return metaKey, true
 		}
 	}
 
 	var keymod KeyModifier
-	switch {
-	case hasCtrl && hasShift:
-		keymod = ModControlShift
-	case hasCtrl:
-		keymod = ModControl
-	case hasShift:
-		keymod = ModShift
+	if hasCtrl {
+		keymod |= ModControl
+	}
+	if hasShift {
+		keymod |= ModShift
+	}
+	if hasAlt {
+		keymod |= ModAlt
+	}
+	if hasMeta {
+		keymod |= ModMeta
 	}
 	if keymod != ModUnknown {
 		switch mappedKey.kind {
@@ -41,6 +41,14 @@ func TestScanKey(t *testing.T) {
 		{[]ebiten.Key{ebiten.KeyA, ebiten.KeyControlLeft, ebiten.KeyShiftLeft}, KeyWithModifier(KeyA, ModControlShift)},
 		{[]ebiten.Key{ebiten.KeyA, ebiten.KeyControlRight, ebiten.KeyShiftRight}, KeyWithModifier(KeyA, ModControlShift)},
 		{[]ebiten.Key{ebiten.KeyControlLeft, ebiten.KeyA, ebiten.KeyShiftRight}, KeyWithModifier(KeyA, ModControlShift)},
+
+		// Alt and Meta modifiers.
+		{[]ebiten.Key{ebiten.KeyAltLeft, ebiten.KeyAlt}, KeyAltLeft},
+		{[]ebiten.Key{ebiten.KeyMetaRight, ebiten.KeyMeta}, KeyMetaRight},
+		{[]ebiten.Key{ebiten.KeyX, ebiten.KeyAltLeft, ebiten.KeyAlt}, KeyWithModifier(KeyX, ModAlt)},
+		{[]ebiten.Key{ebiten.KeyS, ebiten.KeyMetaLeft, ebiten.KeyMeta}, KeyWithModifier(KeyS, ModMeta)},
+		{[]ebiten.Key{ebiten.KeyS, ebiten.KeyMetaLeft, ebiten.KeyShiftLeft}, KeyWithModifier(KeyS, ModMeta|ModShift)},
+		{[]ebiten.Key{ebiten.KeyD, ebiten.KeyControlLeft, ebiten.KeyAltRight, ebiten.KeyShiftLeft, ebiten.KeyMetaLeft}, KeyWithModifier(KeyD, ModControl|ModShift|ModAlt|ModMeta)},
 	}
 
 	for i, test := range tests {
@@ -0,0 +1,303 @@
+package input
+
+import (
+	"math"
+)
+
+// DeadzoneShape selects how the stick deadzone is applied.
+type DeadzoneShape int
+
+const (
+	// DeadzoneDefault is the classic deadzone check:
+	// the stick is active when |x|+|y| reaches the inner deadzone.
+	// The reported stick position is not modified.
+	DeadzoneDefault DeadzoneShape = iota
+
+	// DeadzoneAxial applies the deadzone to every axis separately.
+	// It makes it easier to move the stick strictly along the axis,
+	// but the diagonal movements near the center are lost.
+	DeadzoneAxial
+
+	// DeadzoneRadial ignores the stick positions inside of the inner deadzone circle.
+	// Outside of the circle, the stick position is reported as is.
+	DeadzoneRadial
+
+	// DeadzoneScaledRadial is like DeadzoneRadial, but the stick magnitude
+	// is remapped from the [inner, outer] range to [0, 1].
+	// This way, there is no value jump at the deadzone border.
+	DeadzoneScaledRadial
+)
+
+// StickConfig describes how the raw stick position is processed
+// before it's reported to the handler users.
+//
+// The processed position is used for all stick keys:
+// KeyGamepadLStickMotion event Pos, ActionValue, ActionVector
+// and the stick direction keys like KeyGamepadLStickUp.
+//
+// The zero config keeps the stick position unmodified
+// and uses Handler.GamepadDeadzone for the activation.
+type StickConfig struct {
+	// Deadzone is the deadzone model, see DeadzoneShape.
+	Deadzone DeadzoneShape
+
+	// InnerDeadzone is a stick magnitude (or an axis value for DeadzoneAxial)
+	// that needs to be reached to register a stick movement.
+	// A zero value means Handler.GamepadDeadzone.
+	InnerDeadzone float64
+
+	// OuterDeadzone is a stick magnitude (or an axis value for DeadzoneAxial)
+	// that is treated as a full deflection.
+	// It helps with the worn controllers that can't reach the max value anymore.
+	// A zero value means 1.
+	//
+	// It's ignored for DeadzoneDefault.
+	OuterDeadzone float64
+
+	// Curve is a response curve applied to the stick magnitude.
+	// It maps a [0, 1] magnitude to a [0, 1] value.
+	// A nil curve is linear.
+	//
+	// Use ExponentialCurve for a more precise aiming with
+	// the small stick movements or provide a custom function.
+	Curve func(v float64) float64
+}
+
+// ExponentialCurve returns a StickConfig response curve that raises
+// the stick magnitude to the given power.
+// A power of 2 or 3 makes the small stick movements more precise.
+func ExponentialCurve(power float64) func(v float64) float64 {
+	return func(v float64) float64 {
+		return math.Pow(v, power)
+	}
+}
+
+// StickDPadConfig describes how the stick direction keys,
+// like KeyGamepadLStickUp, emulate the D-pad buttons.
+//
+// Every direction has a 90 degrees sector, widened by the Overlap.
+// A direction is activated when the stick is inside its sector
+// and the stick magnitude reaches the Threshold.
+//
+// The release hysteresis keeps an active direction pressed while the stick
+// stays near the sector border, so menus don't flicker between directions.
+// It relies on the directions state of the previous frame.
+// If the stick direction keys were not checked during the previous frame,
+// that state is computed without the hysteresis.
+type StickDPadConfig struct {
+	// Threshold is a stick magnitude required to activate a direction.
+	Threshold float64
+
+	// FourWay mode allows only one direction at a time, the diagonals are not reported.
+	// The Overlap is ignored in this mode.
+	FourWay bool
+
+	// Overlap is an angle, in radians, added to both sides of every direction sector.
+	// The stick positions near the diagonals activate two directions at once.
+	// A value of π/8 splits the circle into 8 equal sectors.
+	Overlap float64
+
+	// ReleaseThreshold is subtracted from the Threshold for the active directions.
+	// A stick needs to go below that lower magnitude to release the direction.
+	ReleaseThreshold float64
+
+	// ReleaseAngle is an angle, in radians, added to both sides
+	// of the active direction sectors.
+	// A stick needs to leave that wider sector to release the direction.
+	ReleaseAngle float64
+}
+
+// stickDPadState is a lazily updated stick directions state.
+// It doesn't need the handler to be updated by the system,
+// so the stick direction keys are not stateful.
+type stickDPadState struct {
+	// tick is a system tick when this state was updated.
+	tick int
+
+	// pressed and prevPressed are the bitmasks of the active directions
+	// during this and the previous frames, 1<<stickCode per direction.
+	pressed     uint8
+	prevPressed uint8
+}
+
+// stickDPadState returns the up-to-date stickLeft or stickRight directions state.
+// The state is computed once per system tick.
+func (h *Handler) stickDPadState(stick stickCode) *stickDPadState {
+	state := &h.stickDPad[0]
+	if stick == stickRight {
+		state = &h.stickDPad[1]
+	}
+	if state.tick == h.sys.tick {
+		return state
+	}
+
+	prev := state.pressed
+	if state.tick != h.sys.tick-1 {
+		// The state was not computed during the previous frame.
+		prev = h.stickDPadDirections(h.stickPrevVec(stick), 0)
+	}
+	state.tick = h.sys.tick
+	state.prevPressed = prev
+	state.pressed = h.stickDPadDirections(h.stickVec(stick), prev)
+	return state
+}
+
+// stickDPadDirections returns the active directions bitmask for the vec.
+// The directions from the active bitmask are checked with the release hysteresis.
+func (h *Handler) stickDPadDirections(vec Vec, active uint8) uint8 {
+	config := &h.StickDPad
+	magnitude := vecLen(vec)
+	angle := angleNormalized(vecAngle(vec))
+	overlap := config.Overlap
+	if config.FourWay {
+		overlap = 0
+	}
+
+	var pressed uint8
+	for _, code := range [...]stickCode{stickUp, stickRight, stickDown, stickLeft} {
+		bit := uint8(1 << code)
+		threshold := config.Threshold
+		sectorOverlap := overlap
+		if active&bit != 0 {
+			threshold -= config.ReleaseThreshold
+			sectorOverlap += config.ReleaseAngle
+		}
+		if magnitude != 0 && magnitude >= threshold && stickSectorContains(code, angle, sectorOverlap) {
+			pressed |= bit
+		}
+	}
+	if config.FourWay && pressed&active != 0 {
+		// The wider active sector overlaps with its neighbors,
+		// the active direction wins.
+		pressed &= active
+	}
+
+	return pressed
+}
+
+func (h *Handler) gamepadStickIsPressed(k Key) bool {
+	state := h.stickDPadState(keyStick(k))
+	return state.pressed&(1<<k.code) != 0
+}
+
+func (h *Handler) gamepadStickIsJustPressed(k Key) bool {
+	state := h.stickDPadState(keyStick(k))
+	bit := uint8(1 << k.code)
+	return state.pressed&bit != 0 && state.prevPressed&bit == 0
+}
+
+func (h *Handler) gamepadStickIsJustReleased(k Key) bool {
+	state := h.stickDPadState(keyStick(k))
+	bit := uint8(1 << k.code)
+	return state.pressed&bit == 0 && state.prevPressed&bit != 0
+}
+
+// keyStick returns the stickLeft or stickRight stick of the stick direction key.
+func keyStick(k Key) stickCode {
+	if k.kind == keyGamepadRightStick {
+		return stickRight
+	}
+	return stickLeft
+}
+
+// stickSectorContains reports whether the angle is inside of the
+// code direction sector widened by the overlap angle.
+func stickSectorContains(code stickCode, angle, overlap float64) bool {
+	switch code {
+	case stickUp:
+		return angle > (math.Pi+math.Pi/4)-overlap && angle <= (2*math.Pi-math.Pi/4)+overlap
+	case stickRight:
+		return angle <= (math.Pi/4)+overlap || angle > (2*math.Pi-math.Pi/4)-overlap
+	case stickDown:
+		return angle > (math.Pi/4)-overlap && angle <= (math.Pi-math.Pi/4)+overlap
+	case stickLeft:
+		return angle > (math.Pi-math.Pi/4)-overlap && angle <= (math.Pi+math.Pi/4)+overlap
+	}
+	return false
+}
+
+// stickConfig returns the stickLeft or stickRight stick config.
+func (h *Handler) stickConfig(stick stickCode) *StickConfig {
+	if stick == stickLeft {
+		return &h.LeftStickConfig
+	}
+	return &h.RightStickConfig
+}
+
+// applyStickConfig returns a processed raw stick vec.
+func applyStickConfig(config *StickConfig, deadzone float64, vec Vec) Vec {
+	vec = applyStickDeadzone(config, deadzone, vec)
+	if config.Curve != nil {
+		l := vecLen(vec)
+		if l == 0 {
+			return vec
+		}
+		vec = vecWithLen(vec, l, config.Curve(math.Min(l, 1)))
+	}
+	return vec
+}
+
+// applyStickDeadzone is like applyStickConfig, but the response curve is not applied.
+func applyStickDeadzone(config *StickConfig, deadzone float64, vec Vec) Vec {
+	inner := stickInnerDeadzone(config, deadzone)
+	outer := config.OuterDeadzone
+	if outer == 0 {
+		outer = 1
+	}
+
+	switch config.Deadzone {
+	case DeadzoneAxial:
+		vec.X = applyAxialDeadzone(vec.X, inner, outer)
+		vec.Y = applyAxialDeadzone(vec.Y, inner, outer)
+	case DeadzoneRadial:
+		l := vecLen(vec)
+		if l < inner {
+			return Vec{}
+		}
+		if l > outer {
+			vec = vecWithLen(vec, l, 1)
+		}
+	case DeadzoneScaledRadial:
+		l := vecLen(vec)
+		if l < inner {
+			return Vec{}
+		}
+		vec = vecWithLen(vec, l, remapDeadzone(l, inner, outer))
+	}
+
+	return vec
+}
+
+// stickInnerDeadzone returns the config inner deadzone,
+// the handler deadzone is used if it's not set.
+func stickInnerDeadzone(config *StickConfig, deadzone float64) float64 {
+	if config.InnerDeadzone == 0 {
+		return deadzone
+	}
+	return config.InnerDeadzone
+}
+
+func applyAxialDeadzone(v, inner, outer float64) float64 {
+	abs := math.Abs(v)
+	if abs < inner {
+		return 0
+	}
+	if abs > outer {
+		abs = 1
+	}
+	return math.Copysign(abs, v)
+}
+
+// remapDeadzone maps a v from [inner, outer] range to [0, 1].
+func remapDeadzone(v, inner, outer float64) float64 {
+	if outer <= inner {
+		return 1
+	}
+	return math.Max(0, math.Min((v-inner)/(outer-inner), 1))
+}
+
+// vecWithLen returns a vec that has the same direction,
+// but its length is changed from l to newLen.
+func vecWithLen(vec Vec, l, newLen float64) Vec {
+	return Vec{X: vec.X * newLen / l, Y: vec.Y * newLen / l}
+}
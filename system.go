@@ -1,8 +1,9 @@
 package input
 
 import (
+	"math"
+
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 // System is the main component of the input library.
@@ -22,6 +23,14 @@ import (
 // The system is usually not used directly after the input handlers are created.
 // Use input handlers to handle the user input.
 type System struct {
+	backend Backend
+
+	// tick is incremented on every Update.
+	// It's used by the lazily updated handler states, like the stick D-pad emulation.
+	tick int
+
+	gestures GestureConfig
+
 	gamepadIDs  []ebiten.GamepadID
 	gamepadInfo []gamepadInfo
 
@@ -34,40 +43,173 @@ type System struct {
 	simulatedEvents     []simulatedEvent
 	hasSimulatedActions bool
 
+	// Held events are re-emitted every frame until they're released.
+	// The hold/release requests are applied during the next Update.
+	pendingHoldChanges []simulatedHoldChange
+	heldEvents         []simulatedEvent
+
+	// Handlers that have stateful keys in their keymaps, a virtual gamepad
+	// or an input buffer. They're updated after the devices state is read.
+	// See Handler.register.
+	handlers []*Handler
+
+	// All concurrent touches are tracked in the touches slice.
+	// The touch keys like KeyTouchTap follow a single "primary" touch:
+	// the first touch that started when there were no other primary touch.
+	touches          []TouchInfo
 	touchEnabled     bool
 	touchHasTap      bool
 	touchHasLongTap  bool
 	touchJustHadDrag bool
 	touchHasDrag     bool
-	touchDragging    bool
-	touchIDs         []ebiten.TouchID // This is a scratch slice, we don't support multi-touches yet
+	touchIDs         []ebiten.TouchID // This is a scratch slice
 	touchActiveID    ebiten.TouchID
 	touchTapPos      Vec
 	touchDragPos     Vec
 	touchStartPos    Vec
-	touchTime        float64
-
-	mouseEnabled          bool
-	mouseHasDrag          bool // For "drag" event
-	mouseDragging         bool // For "drag" event
-	mouseJustHadDrag      bool // For "drag" event
-	mouseJustReleasedDrag bool // For "drag" event
-	mousePressed          bool // For "drag" event
-	mouseStartPos         Vec  // For "drag" event
-	mouseDragPos          Vec  // For "drag" event
-	cursorPos             Vec
-	wheel                 Vec
+	touchGesture     touchGesture
+	touchSwipe       swipeInfo
+
+	mouseEnabled bool
+	mouseDrags   [3]mouseDragState // Left, middle and right button drags
+	mouseSwipe   swipeInfo
+	cursorPos    Vec
+	wheel        Vec
+}
+
+// mouseDragState is a drag gesture state of a single mouse button.
+type mouseDragState struct {
+	hasDrag          bool
+	dragging         bool
+	justHadDrag      bool
+	justReleasedDrag bool
+	pressed          bool
+	startPos         Vec
+	dragPos          Vec
+	dragTime         float64
+	pathLen          float64
 }
 
 // SystemConfig configures the input system.
-// This configuration can't be changed once created.
+// This configuration can't be changed once created,
+// except for the Gestures that can be adjusted with System.SetGestureConfig.
 type SystemConfig struct {
 	// DevicesEnabled selects the input devices that should be handled.
 	// For the most cases, AnyDevice value is a good option.
 	DevicesEnabled DeviceKind
+
+	// Gestures configures the gesture recognition thresholds.
+	// The zero value is good for the most cases.
+	Gestures GestureConfig
+
+	// Backend is used to read the input devices state.
+	// If nil, EbitenBackend is used.
+	//
+	// See Backend documentation for more info.
+	//
+	// Experimental: this is a part of the backend API, which is not stable yet.
+	Backend Backend
+}
+
+// GestureConfig holds the touch and mouse gesture recognition thresholds.
+//
+// Every zero field is replaced with its default value,
+// so a zero threshold or duration can't be set; use a tiny positive value instead.
+// The negative values are not allowed: Init and SetGestureConfig panic on them.
+// The defaults are good for the most cases, but high-DPI screens
+// or accessibility settings may need different values.
+type GestureConfig struct {
+	// LongTapDuration is a min touch duration, in seconds, to make it a long tap.
+	// The default value is 0.5.
+	LongTapDuration float64
+
+	// TouchDragThreshold is a min touch movement distance, in pixels, to make it a drag.
+	// The default value is 5.
+	TouchDragThreshold float64
+
+	// MouseDragThreshold is a min cursor movement distance, in pixels,
+	// to make a mouse button press a drag.
+	// The mouse pointer is more precise than a finger, so it has a lower threshold.
+	// The default value is 1.
+	MouseDragThreshold float64
+
+	// MultiTapInterval is a max time between the taps, in seconds,
+	// for the KeyMultiTap keys created with a zero interval.
+	// The default value is 0.3.
+	MultiTapInterval float64
+
+	// PinchThreshold is a min two-finger distance change, in pixels, to activate KeyTouchPinch.
+	// The default value is 10.
+	PinchThreshold float64
+
+	// RotateThreshold is a min two-finger angle change, in radians, to activate KeyTouchRotate.
+	// The default value is 0.15 (~8.6 degrees).
+	RotateThreshold float64
+
+	// SwipeMinDistance is a min swipe length, in pixels.
+	// The default value is 30.
+	SwipeMinDistance float64
+
+	// SwipeMinSpeed is a min average swipe speed, in pixels per second.
+	// The default value is 300.
+	SwipeMinSpeed float64
+}
+
+func (c GestureConfig) withDefaults() GestureConfig {
+	fields := [...]struct {
+		name  string
+		value float64
+	}{
+		{"LongTapDuration", c.LongTapDuration},
+		{"TouchDragThreshold", c.TouchDragThreshold},
+		{"MouseDragThreshold", c.MouseDragThreshold},
+		{"MultiTapInterval", c.MultiTapInterval},
+		{"PinchThreshold", c.PinchThreshold},
+		{"RotateThreshold", c.RotateThreshold},
+		{"SwipeMinDistance", c.SwipeMinDistance},
+		{"SwipeMinSpeed", c.SwipeMinSpeed},
+	}
+	for _, f := range fields {
+		if f.value < 0 {
+			panic("negative gesture config " + f.name)
+		}
+	}
+
+	if c.LongTapDuration == 0 {
+		c.LongTapDuration = 0.5
+	}
+	if c.TouchDragThreshold == 0 {
+		c.TouchDragThreshold = 5
+	}
+	if c.MouseDragThreshold == 0 {
+		c.MouseDragThreshold = 1
+	}
+	if c.MultiTapInterval == 0 {
+		c.MultiTapInterval = 0.3
+	}
+	if c.PinchThreshold == 0 {
+		c.PinchThreshold = 10
+	}
+	if c.RotateThreshold == 0 {
+		c.RotateThreshold = 0.15
+	}
+	if c.SwipeMinDistance == 0 {
+		c.SwipeMinDistance = 30
+	}
+	if c.SwipeMinSpeed == 0 {
+		c.SwipeMinSpeed = 300
+	}
+	return c
 }
 
 func (sys *System) Init(config SystemConfig) {
+	sys.backend = config.Backend
+	if sys.backend == nil {
+		sys.backend = EbitenBackend{}
+	}
+
+	sys.gestures = config.Gestures.withDefaults()
+
 	sys.keySlice = make([]ebiten.Key, 0, 4)
 	sys.gamepadKeySlice = make([]ebiten.GamepadButton, 0, 2)
 
@@ -79,12 +221,35 @@ func (sys *System) Init(config SystemConfig) {
 
 	if sys.touchEnabled {
 		sys.touchIDs = make([]ebiten.TouchID, 0, 8)
+		sys.touches = make([]TouchInfo, 0, 8)
 		sys.touchActiveID = -1
 	}
 }
 
+// GestureConfig returns the current gesture recognition thresholds.
+// The zero fields of the config passed to the system are reported as their default values.
+func (sys *System) GestureConfig() GestureConfig {
+	return sys.gestures
+}
+
+// SetGestureConfig changes the gesture recognition thresholds.
+// It can be called at any moment, the new values are used starting from the next Update.
+//
+// It panics if any of the config values is negative.
+//
+// See GestureConfig documentation for more info.
+func (sys *System) SetGestureConfig(config GestureConfig) {
+	sys.gestures = config.withDefaults()
+}
+
 // UpdateWithDelta is like Update(), but it allows you to specify the time delta.
+//
+// If the system backend implements FrameDeltaBackend, like Player does,
+// the backend can override the time delta.
 func (sys *System) UpdateWithDelta(delta float64) {
+	sys.tick++
+	delta = updateBackend(sys.backend, delta)
+
 	// Rotate the events slices.
 	// Pending events become simulated in this frame.
 	// Re-use the other slice capacity to push new events.
@@ -94,6 +259,10 @@ func (sys *System) UpdateWithDelta(delta float64) {
 	sys.prevSimulatedEvents, sys.pendingEvents, sys.simulatedEvents =
 		sys.simulatedEvents, sys.prevSimulatedEvents, sys.pendingEvents
 	sys.pendingEvents = sys.pendingEvents[:0]
+	if len(sys.pendingHoldChanges) != 0 {
+		sys.applyHoldChanges()
+	}
+	sys.simulatedEvents = append(sys.simulatedEvents, sys.heldEvents...)
 	sys.hasSimulatedActions = false
 	for i := range sys.simulatedEvents {
 		if sys.simulatedEvents[i].keyKind == keySimulated {
@@ -102,16 +271,16 @@ func (sys *System) UpdateWithDelta(delta float64) {
 		}
 	}
 
-	sys.gamepadIDs = ebiten.AppendGamepadIDs(sys.gamepadIDs[:0])
+	sys.gamepadIDs = sys.backend.AppendGamepadIDs(sys.gamepadIDs[:0])
 	if len(sys.gamepadIDs) != 0 {
 		for i, id := range sys.gamepadIDs {
 			info := &sys.gamepadInfo[i]
-			info.axisCount = ebiten.GamepadAxisCount(id)
-			modelName := ebiten.GamepadName(id)
+			info.axisCount = sys.backend.GamepadAxisCount(id)
+			modelName := sys.backend.GamepadName(id)
 			if info.modelName != modelName {
 				info.modelName = modelName
 				switch {
-				case ebiten.IsStandardGamepadLayoutAvailable(id):
+				case sys.backend.IsStandardGamepadLayoutAvailable(id):
 					info.model = gamepadStandard
 				case isFirefox():
 					info.model = guessFirefoxGamepadModel(int(id))
@@ -124,99 +293,89 @@ func (sys *System) UpdateWithDelta(delta float64) {
 	}
 
 	if sys.touchEnabled {
-		sys.touchHasTap = false
-		sys.touchHasLongTap = false
-		sys.touchHasDrag = false
-		sys.touchJustHadDrag = false
-		// Track the touch gesture release.
-		// If it was a tap, set a flag.
-		if sys.touchActiveID != -1 && inpututil.IsTouchJustReleased(sys.touchActiveID) {
-			if !sys.touchDragging {
-				if sys.touchTime >= 0.5 {
-					sys.touchHasLongTap = true
-				} else {
-					sys.touchHasTap = true
-				}
-				sys.touchTapPos = sys.touchStartPos
-			}
-			sys.touchActiveID = -1
-			sys.touchDragging = false
-		}
-		// Check if this gesture entered a drag mode.
-		// Drag mode gestures will not trigger a tap when released.
-		// Drag events emit a pos delta relative to a start pos every frame.
-		if sys.touchActiveID != -1 {
-			x, y := ebiten.TouchPosition(sys.touchActiveID)
-			currentPos := Vec{X: float64(x), Y: float64(y)}
-			if sys.touchDragging {
-				sys.touchHasDrag = true
-				sys.touchDragPos = currentPos
-			} else {
-				sys.touchTime += delta
-				if vecDistance(sys.touchStartPos, currentPos) > 5 {
-					sys.touchDragging = true
-					sys.touchJustHadDrag = true
-					sys.touchHasDrag = true
-					sys.touchDragPos = currentPos
-				}
-			}
-		}
-		// Check if a new touch gesture is started.
-		if sys.touchActiveID == -1 {
-			sys.touchIDs = inpututil.AppendJustPressedTouchIDs(sys.touchIDs[:0])
-			for _, id := range sys.touchIDs {
-				x, y := ebiten.TouchPosition(id)
-				sys.touchStartPos = Vec{X: float64(x), Y: float64(y)}
-				sys.touchActiveID = id
-				sys.touchTime = 0
-				break
-			}
+		sys.updateTouches(delta)
+	}
+	// The virtual gamepads are updated before the touch gestures,
+	// so the touches they own don't become the gestures.
+	// The stateful keys can depend on them too.
+	for _, h := range sys.handlers {
+		if h.virtualGamepad != nil {
+			h.virtualGamepad.update(sys.touches)
 		}
 	}
+	if sys.touchEnabled {
+		sys.updatePrimaryTouch()
+		sys.updateTouchGesture()
+	}
 
 	if sys.mouseEnabled {
-		x, y := ebiten.CursorPosition()
+		x, y := sys.backend.CursorPosition()
+		prevCursorPos := sys.cursorPos
 		sys.cursorPos = Vec{X: float64(x), Y: float64(y)}
 
-		// We copy a lot from the touch-style drag gesture.
-		// This is not mandatory as getting a cursor pos is much easier on PC.
-		// But I do value the consistency and easier cross-platform coding,
-		// so let's try to make them behave as close to each other as feasible.
-		sys.mouseHasDrag = false
-		sys.mouseJustHadDrag = false
-		sys.mouseJustReleasedDrag = false
-		if inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
-			if sys.mouseDragging {
-				sys.mouseJustReleasedDrag = true
-			}
-			sys.mouseDragging = false
-			sys.mousePressed = false
-		}
-		if sys.mousePressed {
-			if sys.mouseDragging {
-				sys.mouseHasDrag = true
-				sys.mouseDragPos = sys.cursorPos
-			} else {
-				// Mouse pointer is more precise than a finger gesture,
-				// therefore we can have a lower threshold here.
-				if vecDistance(sys.mouseStartPos, sys.cursorPos) > 1 {
-					sys.mouseDragging = true
-					sys.mouseJustHadDrag = true
-					sys.mouseHasDrag = true
-					sys.mouseDragPos = sys.cursorPos
-				}
-			}
-		}
-		if !sys.mousePressed && inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-			sys.mouseStartPos = sys.cursorPos
-			sys.mousePressed = true
+		sys.mouseSwipe = swipeInfo{}
+		for i := range sys.mouseDrags {
+			sys.updateMouseDrag(ebiten.MouseButton(i), prevCursorPos, delta)
 		}
 	}
 
 	if sys.mouseEnabled || sys.touchEnabled {
-		x, y := ebiten.Wheel()
+		x, y := sys.backend.Wheel()
 		sys.wheel = Vec{X: x, Y: y}
 	}
+
+	for _, h := range sys.handlers {
+		h.updateKeyStates(delta)
+		if h.bufferTicks != 0 {
+			h.updateActionBuffer()
+		}
+	}
+}
+
+// updateMouseDrag updates the drag gesture state of the given mouse button.
+// The left button drags can also produce the swipes.
+func (sys *System) updateMouseDrag(button ebiten.MouseButton, prevCursorPos Vec, delta float64) {
+	// We copy a lot from the touch-style drag gesture.
+	// This is not mandatory as getting a cursor pos is much easier on PC.
+	// But I do value the consistency and easier cross-platform coding,
+	// so let's try to make them behave as close to each other as feasible.
+	d := &sys.mouseDrags[button]
+	d.hasDrag = false
+	d.justHadDrag = false
+	d.justReleasedDrag = false
+	if sys.backend.IsMouseButtonJustReleased(button) {
+		if d.dragging {
+			d.justReleasedDrag = true
+			if button == ebiten.MouseButtonLeft {
+				sys.mouseSwipe = detectSwipe(&sys.gestures, d.startPos, sys.cursorPos, d.pathLen, d.dragTime)
+			}
+		}
+		d.dragging = false
+		d.pressed = false
+	}
+	if d.pressed {
+		d.dragTime += delta
+		d.pathLen += vecDistance(prevCursorPos, sys.cursorPos)
+		if d.dragging {
+			d.hasDrag = true
+			d.dragPos = sys.cursorPos
+		} else {
+			// Mouse pointer is more precise than a finger gesture,
+			// therefore we can have a lower threshold here.
+			if vecDistance(d.startPos, sys.cursorPos) > sys.gestures.MouseDragThreshold {
+				d.dragging = true
+				d.justHadDrag = true
+				d.hasDrag = true
+				d.dragPos = sys.cursorPos
+			}
+		}
+	}
+	if !d.pressed && sys.backend.IsMouseButtonJustPressed(button) {
+		d.startPos = sys.cursorPos
+		d.pressed = true
+		d.dragTime = 0
+		d.pathLen = 0
+	}
 }
 
 // Update reads the input state and updates the information
@@ -233,38 +392,275 @@ func (sys *System) Update() {
 	sys.UpdateWithDelta(1.0 / 60.0)
 }
 
+func (sys *System) updateTouches(delta float64) {
+	sys.touchHasTap = false
+	sys.touchHasLongTap = false
+	sys.touchHasDrag = false
+	sys.touchJustHadDrag = false
+	sys.touchSwipe = swipeInfo{}
+
+	// The touches released during the previous frame are not tracked anymore.
+	touches := sys.touches[:0]
+	for _, t := range sys.touches {
+		if !t.justReleased {
+			touches = append(touches, t)
+		}
+	}
+	sys.touches = touches
+
+	// Track the touch gestures release.
+	// If it was a tap, set a flag.
+	// Check if the active gestures entered a drag mode.
+	// Drag mode gestures will not trigger a tap when released.
+	for i := range sys.touches {
+		t := &sys.touches[i]
+		t.justPressed = false
+		t.justDragged = false
+		if sys.backend.IsTouchJustReleased(t.ID) {
+			t.justReleased = true
+			if !t.dragging {
+				if t.Time >= sys.gestures.LongTapDuration {
+					t.longTap = true
+				} else {
+					t.tap = true
+				}
+			}
+			continue
+		}
+		x, y := sys.backend.TouchPosition(t.ID)
+		pos := Vec{X: float64(x), Y: float64(y)}
+		t.pathLen += vecDistance(t.Pos, pos)
+		t.Pos = pos
+		t.Time += delta
+		if !t.dragging && vecDistance(t.StartPos, t.Pos) > sys.gestures.TouchDragThreshold {
+			t.dragging = true
+			t.justDragged = true
+		}
+	}
+
+	// Check if new touch gestures are started.
+	sys.touchIDs = sys.backend.AppendJustPressedTouchIDs(sys.touchIDs[:0])
+	for _, id := range sys.touchIDs {
+		x, y := sys.backend.TouchPosition(id)
+		pos := Vec{X: float64(x), Y: float64(y)}
+		sys.touches = append(sys.touches, TouchInfo{
+			ID:          id,
+			StartPos:    pos,
+			Pos:         pos,
+			justPressed: true,
+		})
+	}
+}
+
+// updatePrimaryTouch updates the primary touch gesture state.
+// The touches owned by the virtual gamepads are never primary.
+func (sys *System) updatePrimaryTouch() {
+	// Drag events emit a pos delta relative to a start pos every frame.
+	if sys.touchActiveID != -1 {
+		t := sys.findTouch(sys.touchActiveID)
+		switch {
+		case t == nil || t.virtual:
+			// A touch that slides into a virtual button is dropped
+			// without producing any gestures.
+			sys.touchActiveID = -1
+		case t.justReleased:
+			sys.touchHasTap = t.tap
+			sys.touchHasLongTap = t.longTap
+			if t.tap || t.longTap {
+				sys.touchTapPos = t.StartPos
+			}
+			if t.dragging {
+				sys.touchSwipe = detectSwipe(&sys.gestures, t.StartPos, t.Pos, t.pathLen, t.Time)
+			}
+			sys.touchActiveID = -1
+		case t.dragging:
+			sys.touchHasDrag = true
+			sys.touchJustHadDrag = t.justDragged
+			sys.touchDragPos = t.Pos
+		}
+	}
+	if sys.touchActiveID == -1 {
+		for _, id := range sys.touchIDs {
+			t := sys.findTouch(id)
+			if t.virtual {
+				continue
+			}
+			sys.touchActiveID = t.ID
+			sys.touchStartPos = t.StartPos
+			break
+		}
+	}
+}
+
+// updateTouchGesture tracks the two-finger gesture formed by the two oldest active touches.
+func (sys *System) updateTouchGesture() {
+	g := &sys.touchGesture
+	g.justPinched = false
+	g.justRotated = false
+	g.pinchJustReleased = false
+	g.rotateJustReleased = false
+	g.scaleDelta = 0
+	g.rotationDelta = 0
+
+	var t1, t2 *TouchInfo
+	for i := range sys.touches {
+		t := &sys.touches[i]
+		if t.justReleased || t.virtual {
+			continue
+		}
+		if t1 == nil {
+			t1 = t
+		} else {
+			t2 = t
+			break
+		}
+	}
+
+	if t2 == nil || !g.active || g.ids != [2]ebiten.TouchID{t1.ID, t2.ID} {
+		// The gesture is finished or the touches have changed.
+		if g.active {
+			g.pinchJustReleased = g.pinching
+			g.rotateJustReleased = g.rotating
+		}
+		g.active = false
+		g.pinching = false
+		g.rotating = false
+		if t2 == nil {
+			return
+		}
+		// Start a new gesture.
+		v := Vec{X: t2.Pos.X - t1.Pos.X, Y: t2.Pos.Y - t1.Pos.Y}
+		g.active = true
+		g.ids = [2]ebiten.TouchID{t1.ID, t2.ID}
+		g.dist = vecLen(v)
+		g.angle = vecAngle(v)
+		g.center = Vec{X: (t1.Pos.X + t2.Pos.X) / 2, Y: (t1.Pos.Y + t2.Pos.Y) / 2}
+		g.startDist = g.dist
+		g.startAngle = g.angle
+		g.startCenter = g.center
+		return
+	}
+
+	v := Vec{X: t2.Pos.X - t1.Pos.X, Y: t2.Pos.Y - t1.Pos.Y}
+	dist := vecLen(v)
+	angle := vecAngle(v)
+	prevDist := g.dist
+	prevAngle := g.angle
+	g.dist = dist
+	g.angle = angle
+	g.center = Vec{X: (t1.Pos.X + t2.Pos.X) / 2, Y: (t1.Pos.Y + t2.Pos.Y) / 2}
+
+	if !g.pinching && math.Abs(dist-g.startDist) > sys.gestures.PinchThreshold {
+		g.pinching = true
+		g.justPinched = true
+		// Report the entire change, so the movement before
+		// the gesture activation is not lost.
+		prevDist = g.startDist
+	}
+	if !g.rotating && math.Abs(angleDelta(g.startAngle, angle)) > sys.gestures.RotateThreshold {
+		g.rotating = true
+		g.justRotated = true
+		prevAngle = g.startAngle
+	}
+	if prevDist != 0 {
+		g.scaleDelta = dist/prevDist - 1
+	}
+	g.rotationDelta = angleDelta(prevAngle, angle)
+}
+
+func (sys *System) findTouch(id ebiten.TouchID) *TouchInfo {
+	return findTouchInfo(sys.touches, id)
+}
+
+func (sys *System) applyHoldChanges() {
+	for _, c := range sys.pendingHoldChanges {
+		i := heldEventIndex(sys.heldEvents, c.e)
+		switch {
+		case c.release && i != -1:
+			sys.heldEvents = append(sys.heldEvents[:i], sys.heldEvents[i+1:]...)
+		case c.release:
+			// Releasing a key that is not being held is a no-op.
+		case i != -1:
+			// Holding the same key again only updates its event data.
+			sys.heldEvents[i] = c.e
+		default:
+			sys.heldEvents = append(sys.heldEvents, c.e)
+		}
+	}
+	sys.pendingHoldChanges = sys.pendingHoldChanges[:0]
+}
+
 func (sys *System) updateGamepadInfo(id ebiten.GamepadID, info *gamepadInfo) {
 	switch info.model {
 	case gamepadStandard:
 		copy(info.prevAxisValues[:], info.axisValues[:])
 		for axis := ebiten.StandardGamepadAxisLeftStickHorizontal; axis <= ebiten.StandardGamepadAxisMax; axis++ {
-			v := ebiten.StandardGamepadAxisValue(id, axis)
+			v := sys.backend.StandardGamepadAxisValue(id, axis)
 			info.axisValues[int(axis)] = v
 		}
+		info.prevTriggerValues = info.triggerValues
+		info.triggerValues[0] = standardGamepadButtonValue(sys.backend, id, ebiten.StandardGamepadButtonFrontBottomLeft)
+		info.triggerValues[1] = standardGamepadButtonValue(sys.backend, id, ebiten.StandardGamepadButtonFrontBottomRight)
 	case gamepadFirefoxXinput:
 		copy(info.prevAxisValues[:], info.axisValues[:])
 		for axis := 0; axis < info.axisCount; axis++ {
-			v := ebiten.GamepadAxisValue(id, axis)
+			v := sys.backend.GamepadAxisValue(id, axis)
 			info.axisValues[axis] = v
 		}
 	}
 }
 
+// RemoveHandler detaches the handler from the system.
+//
+// Some handlers are updated by the system during every Update call,
+// like the handlers with KeySequence or KeyHold keys in their keymaps.
+// Call this method for the handlers that are not needed anymore,
+// like the handlers of the finished game scene, so they can be garbage collected.
+//
+// The removed handler should not be used after that.
+func (sys *System) RemoveHandler(h *Handler) {
+	if h.sys != sys {
+		panic("the handler belongs to another system")
+	}
+	h.removed = true
+	h.register()
+}
+
 // NewHandler creates a handler associated with player/device ID.
 // IDs should start with 0 with a step of 1.
 // So, NewHandler(0, ...) then NewHandler(1, ...).
 //
 // If you want to configure the handler further, use Handler fields/methods
 // to do that. For example, see Handler.GamepadDeadzone.
+//
+// The system keeps a reference to the handlers that need a per-frame update:
+// the ones with the stateful keys in their keymaps (KeySequence, KeyHold, KeyTap,
+// KeyMultiTap, KeyRepeat, KeyAxis, KeyVector2), a virtual gamepad or an input buffer.
+// Such handlers are updated during every Update call and are never garbage collected
+// until RemoveHandler is called, even if they're not used anymore.
+// If the handlers are created per game scene, remove them when the scene is finished.
 func (sys *System) NewHandler(playerID uint8, keymap Keymap) *Handler {
-	return &Handler{
-		id:     playerID,
-		keymap: keymap,
-		sys:    sys,
+	h := &Handler{
+		id:         playerID,
+		keymap:     keymap,
+		baseKeymap: keymap,
+		sys:        sys,
 
 		// My gamepads may have false positive activations with a
 		// value lower than 0.03; we're using 0.055 here just to be safe.
 		// Various sources indicate that a value of ~0.05 is optimal for a default.
 		GamepadDeadzone: 0.055,
+
+		TriggerThreshold:  0.5,
+		TriggerHysteresis: 0.05,
+
+		// The default overlap is slightly wider than the π/8 that makes
+		// all 8 directions equal, it makes the diagonals easier to hit.
+		StickDPad: StickDPadConfig{
+			Threshold: 0.5,
+			Overlap:   math.Pi / 7,
+		},
 	}
+	h.initKeyStates()
+	return h
 }
@@ -0,0 +1,96 @@
+package input
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// TouchInfo describes a single screen touch gesture.
+//
+// Every concurrent touch is tracked separately, use Handler.AppendTouches
+// to iterate over them. This is useful for the multi-touch controls,
+// like two-thumb virtual sticks or a split-screen touch play.
+//
+// A touch is tracked from the frame it was pressed up to
+// the frame it was released, including that release frame.
+//
+// Experimental: touch API is not stable yet!
+type TouchInfo struct {
+	// ID is the touch identifier, as reported by the ebitengine.
+	ID ebiten.TouchID
+
+	// StartPos is the position where the touch was started.
+	StartPos Vec
+
+	// Pos is the current touch position.
+	// For the released touches, it's the last known position.
+	Pos Vec
+
+	// Time is the touch duration, in seconds.
+	// The time is measured using the System.UpdateWithDelta time delta.
+	Time float64
+
+	justPressed  bool
+	justReleased bool
+	dragging     bool
+	justDragged  bool
+	tap          bool
+	longTap      bool
+
+	// virtual is set for the touches that control the virtual gamepad
+	// buttons and sticks. Such touches don't produce the touch gestures.
+	virtual bool
+
+	// pathLen is a total distance traveled by this touch.
+	pathLen float64
+}
+
+// IsJustPressed reports whether this touch was started during this frame.
+func (t TouchInfo) IsJustPressed() bool { return t.justPressed }
+
+// IsJustReleased reports whether this touch was released during this frame.
+func (t TouchInfo) IsJustReleased() bool { return t.justReleased }
+
+// IsDrag reports whether this touch moved far enough from its start position
+// to become a drag gesture. Drag gestures do not produce taps.
+func (t TouchInfo) IsDrag() bool { return t.dragging }
+
+// IsJustDragged reports whether this touch became a drag gesture during this frame.
+func (t TouchInfo) IsJustDragged() bool { return t.justDragged }
+
+// IsTap reports whether this touch was just released and it was a tap.
+// This is a per-touch version of KeyTouchTap.
+func (t TouchInfo) IsTap() bool { return t.tap }
+
+// IsLongTap reports whether this touch was just released and it was a long tap.
+// This is a per-touch version of KeyTouchLongTap.
+func (t TouchInfo) IsLongTap() bool { return t.longTap }
+
+// touchGesture is a two-finger gesture state.
+type touchGesture struct {
+	ids    [2]ebiten.TouchID
+	active bool
+
+	startDist   float64
+	startAngle  float64
+	startCenter Vec
+	dist        float64
+	angle       float64
+	center      Vec
+
+	scaleDelta    float64
+	rotationDelta float64
+
+	pinching           bool
+	justPinched        bool
+	pinchJustReleased  bool
+	rotating           bool
+	justRotated        bool
+	rotateJustReleased bool
+}
+
+// angleDelta returns the shortest signed angle from a to b.
+func angleDelta(a, b float64) float64 {
+	return math.Remainder(b-a, 2*math.Pi)
+}
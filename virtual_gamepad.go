@@ -0,0 +1,257 @@
+package input
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// VirtualGamepad is an on-screen gamepad for the touch devices.
+//
+// It's rendering-agnostic: the library only tracks the touches
+// inside the screen regions, the drawing is up to the game.
+// Use the buttons and sticks state methods to draw them.
+//
+// Bind it to a handler using Handler.SetVirtualGamepad.
+// A virtual gamepad should not be shared between several handlers.
+// The virtual buttons and sticks feed the same keymap as the real gamepad:
+// a virtual button activates its gamepad button key and a virtual stick
+// moves the KeyGamepadLStickMotion (or KeyGamepadRStickMotion) vector.
+// The touches that control the virtual buttons and sticks don't produce
+// the touch gestures, like KeyTouchTap, KeyTouchDrag or KeyTouchSwipe.
+//
+// The regions can be changed on the fly, like when the screen layout changes.
+//
+// Experimental: this is a part of virtual input API, which is not stable yet.
+type VirtualGamepad struct {
+	Buttons []*VirtualButton
+
+	LeftStick  *VirtualStick
+	RightStick *VirtualStick
+}
+
+// VirtualButton is a screen rectangle that acts like a gamepad button.
+//
+// The button is pressed while there is a touch inside its rectangle.
+// Sliding a finger into the button presses it, sliding out of it releases it.
+// The touches that control the virtual sticks are ignored.
+type VirtualButton struct {
+	// Key is a gamepad button key activated by this button, like KeyGamepadA.
+	Key Key
+
+	// Min and Max are the button rectangle corners, in screen coordinates.
+	Min Vec
+	Max Vec
+
+	pressed      bool
+	justPressed  bool
+	justReleased bool
+}
+
+// IsPressed reports whether the button is being touched.
+func (b *VirtualButton) IsPressed() bool { return b.pressed }
+
+// IsJustPressed reports whether the button was touched during this frame.
+func (b *VirtualButton) IsJustPressed() bool { return b.justPressed }
+
+// IsJustReleased reports whether the button was released during this frame.
+func (b *VirtualButton) IsJustReleased() bool { return b.justReleased }
+
+// VirtualStick is an on-screen analog stick.
+//
+// A stick is activated by a touch that starts inside its area.
+// It follows that touch until it's released.
+// The knob offset from the stick center becomes the stick value,
+// a vector in [-1, 1] range for both axes.
+type VirtualStick struct {
+	// Center is the stick home position, in screen coordinates.
+	Center Vec
+
+	// Radius is a max knob offset from the stick center, in pixels.
+	Radius float64
+
+	// AreaMin and AreaMax define a screen rectangle where a touch can activate the stick.
+	// If the area is empty, the stick circle is used instead.
+	AreaMin Vec
+	AreaMax Vec
+
+	// Floating sticks are centered at the position where the touch started.
+	// Fixed sticks always use the Center.
+	Floating bool
+
+	active     bool
+	prevActive bool
+	touchID    ebiten.TouchID
+	origin     Vec
+	knobPos    Vec
+	value      Vec
+	prevValue  Vec
+}
+
+// IsActive reports whether the stick is being touched.
+func (s *VirtualStick) IsActive() bool { return s.active }
+
+// Origin returns the current stick center.
+// For the floating sticks, it's the touch start position while the stick is active.
+func (s *VirtualStick) Origin() Vec {
+	if s.active {
+		return s.origin
+	}
+	return s.Center
+}
+
+// KnobPos returns the stick knob position, in screen coordinates.
+// The knob is never farther than Radius from the Origin.
+func (s *VirtualStick) KnobPos() Vec {
+	if s.active {
+		return s.knobPos
+	}
+	return s.Center
+}
+
+// Value returns the stick value, a vector in [-1, 1] range for both axes.
+// It's a zero vector if the stick is not active.
+func (s *VirtualStick) Value() Vec { return s.value }
+
+func (s *VirtualStick) areaContains(pos Vec) bool {
+	if s.AreaMin == s.AreaMax {
+		return vecDistance(s.Center, pos) <= s.Radius
+	}
+	return rectContains(s.AreaMin, s.AreaMax, pos)
+}
+
+func (s *VirtualStick) update(touches []TouchInfo) {
+	s.prevActive = s.active
+	s.prevValue = s.value
+
+	if s.active {
+		t := findTouchInfo(touches, s.touchID)
+		if t == nil || t.justReleased {
+			s.active = false
+			s.value = Vec{}
+			return
+		}
+		s.follow(t.Pos)
+		return
+	}
+
+	for i := range touches {
+		t := &touches[i]
+		if !t.justPressed || !s.areaContains(t.Pos) {
+			continue
+		}
+		t.virtual = true
+		s.active = true
+		s.touchID = t.ID
+		s.origin = s.Center
+		if s.Floating {
+			s.origin = t.Pos
+		}
+		s.follow(t.Pos)
+		return
+	}
+}
+
+func (s *VirtualStick) follow(pos Vec) {
+	offset := Vec{X: pos.X - s.origin.X, Y: pos.Y - s.origin.Y}
+	if s.Radius <= 0 {
+		s.knobPos = s.origin
+		s.value = Vec{}
+		return
+	}
+	if dist := vecLen(offset); dist > s.Radius {
+		offset.X *= s.Radius / dist
+		offset.Y *= s.Radius / dist
+	}
+	s.knobPos = Vec{X: s.origin.X + offset.X, Y: s.origin.Y + offset.Y}
+	s.value = Vec{X: offset.X / s.Radius, Y: offset.Y / s.Radius}
+}
+
+func (g *VirtualGamepad) update(touches []TouchInfo) {
+	if g.LeftStick != nil {
+		g.LeftStick.update(touches)
+	}
+	if g.RightStick != nil {
+		g.RightStick.update(touches)
+	}
+
+	for _, b := range g.Buttons {
+		wasPressed := b.pressed
+		b.pressed = false
+		for i := range touches {
+			t := &touches[i]
+			if t.justReleased || g.stickOwnsTouch(t.ID) {
+				continue
+			}
+			if rectContains(b.Min, b.Max, t.Pos) {
+				t.virtual = true
+				b.pressed = true
+				break
+			}
+		}
+		b.justPressed = !wasPressed && b.pressed
+		b.justReleased = wasPressed && !b.pressed
+	}
+}
+
+func (g *VirtualGamepad) stickOwnsTouch(id ebiten.TouchID) bool {
+	return (g.LeftStick != nil && g.LeftStick.active && g.LeftStick.touchID == id) ||
+		(g.RightStick != nil && g.RightStick.active && g.RightStick.touchID == id)
+}
+
+func (h *Handler) virtualStick(stick stickCode) *VirtualStick {
+	if h.virtualGamepad == nil {
+		return nil
+	}
+	if stick == stickLeft {
+		return h.virtualGamepad.LeftStick
+	}
+	return h.virtualGamepad.RightStick
+}
+
+func (h *Handler) virtualButtonIsPressed(k Key) bool {
+	if h.virtualGamepad == nil {
+		return false
+	}
+	for _, b := range h.virtualGamepad.Buttons {
+		if b.Key == k && b.pressed {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) virtualButtonIsJustPressed(k Key) bool {
+	if h.virtualGamepad == nil {
+		return false
+	}
+	for _, b := range h.virtualGamepad.Buttons {
+		if b.Key == k && b.justPressed {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) virtualButtonIsJustReleased(k Key) bool {
+	if h.virtualGamepad == nil {
+		return false
+	}
+	for _, b := range h.virtualGamepad.Buttons {
+		if b.Key == k && b.justReleased {
+			return true
+		}
+	}
+	return false
+}
+
+func findTouchInfo(touches []TouchInfo, id ebiten.TouchID) *TouchInfo {
+	for i := range touches {
+		if touches[i].ID == id {
+			return &touches[i]
+		}
+	}
+	return nil
+}
+
+func rectContains(min, max, pos Vec) bool {
+	return pos.X >= min.X && pos.X <= max.X && pos.Y >= min.Y && pos.Y <= max.Y
+}